@@ -1,12 +1,21 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/charmbracelet/log"
-	"github.com/joshbeard/web-indexer/internal/webindexer"
+	"github.com/joshbeard/web-indexer/pkg/webindexer"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -15,8 +24,9 @@ import (
 var version = "dev"
 
 var (
-	cfg    webindexer.Config
-	exiter Exiter = DefaultExiter{}
+	cfg      webindexer.Config
+	jobNames []string
+	exiter   Exiter = DefaultExiter{}
 )
 
 var rootCmd = &cobra.Command{
@@ -40,6 +50,18 @@ var rootCmd = &cobra.Command{
 		"    web-indexer --source s3://bucket/path --target /path/to/directory",
 		"  Index an S3 bucket and upload the index file to the same bucket and path",
 		"    web-indexer --source s3://bucket/path --target s3://bucket/path",
+		"  Index a Google Cloud Storage bucket and upload the index file to the same bucket and path",
+		"    web-indexer --source gs://bucket/path --target gs://bucket/path",
+		"  Index an Azure Blob Storage container and upload the index file to the same container and path",
+		"    web-indexer --azure-account account --source az://container/path --target az://container/path",
+		"  Index a directory on a remote host over SFTP and upload the index files to it",
+		"    web-indexer --source sftp://user@host/path --target sftp://user@host/path",
+		"  Crawl an existing Apache or nginx directory listing and write new index files locally",
+		"    web-indexer --recursive --source https://mirror.example.com/pub/ --target /path/to/directory",
+		"  Index the contents of a zip or tar archive without extracting it",
+		"    web-indexer --recursive --source release.tar.gz --target /path/to/directory",
+		"  Index a tag in a git repository without checking it out",
+		"    web-indexer --recursive --source 'git+file:///path/to/repo?ref=v1.2.0' --target /path/to/directory",
 		"",
 		"  Run with a custom configuration file",
 		"    web-indexer -c custom.yml /path/to/source /path/to/target",
@@ -51,7 +73,7 @@ var rootCmd = &cobra.Command{
 		"      --title 'Index of {relativePath}'",
 	}, "\n"),
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := run(args); err != nil {
+		if err := run(cmd.Context(), args); err != nil {
 			fmt.Println("FATAL:", err)
 			exiter.Exit(1)
 		}
@@ -63,7 +85,7 @@ func main() {
 	cobra.CheckErr(err)
 }
 
-func run(args []string) error {
+func run(ctx context.Context, args []string) error {
 	err := viper.Unmarshal(&cfg)
 	if err != nil {
 		return fmt.Errorf("unable to unmarshal config: %w", err)
@@ -82,20 +104,187 @@ func run(args []string) error {
 		return fmt.Errorf("unable to setup logger: %w", err)
 	}
 
+	// Stop cleanly on Ctrl-C or when a CI job is canceled. Index files are
+	// written atomically, so an interrupted run leaves no partial files.
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if len(cfg.Jobs) > 0 {
+		if len(args) > 0 {
+			return fmt.Errorf("the source and target can't be passed as arguments with jobs")
+		}
+
+		jobs, err := cfg.ResolveJobs(jobNames)
+		if err != nil {
+			return fmt.Errorf("unable to load jobs: %w", err)
+		}
+
+		return runJobs(ctx, jobs, cfg.ParallelJobs)
+	}
+
+	if len(jobNames) > 0 {
+		return fmt.Errorf("--job requires jobs in the configuration file")
+	}
+
+	return runIndexer(ctx, "", cfg, os.Stdout)
+}
+
+// runJobs runs the jobs one at a time, or all at once if parallel is set.
+// Every job is run even if others fail, and an error naming the failed jobs
+// is returned.
+func runJobs(ctx context.Context, jobs []webindexer.Job, parallel bool) error {
+	errs := make([]error, len(jobs))
+
+	// Dry run plans are buffered so that jobs running at once don't
+	// interleave them
+	var mu sync.Mutex
+	runJob := func(n int) {
+		job := jobs[n]
+		log.Infof("Running job %s", job.Name)
+
+		var plan bytes.Buffer
+		errs[n] = runIndexer(ctx, job.Name, job.Config, &plan)
+		if errs[n] != nil {
+			log.Errorf("Job %s failed: %v", job.Name, errs[n])
+		}
+
+		if plan.Len() > 0 {
+			mu.Lock()
+			fmt.Fprintf(os.Stdout, "Job %s:\n%s\n", job.Name, plan.String())
+			mu.Unlock()
+		}
+	}
+
+	if parallel {
+		var wg sync.WaitGroup
+		for n := range jobs {
+			wg.Go(func() { runJob(n) })
+		}
+		wg.Wait()
+	} else {
+		for n := range jobs {
+			runJob(n)
+		}
+	}
+
+	var failed []string
+	for n, err := range errs {
+		if err != nil {
+			failed = append(failed, jobs[n].Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d jobs failed: %s", len(failed), len(jobs), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// runIndexer generates the index files for a configuration, named job if it's
+// one of the jobs of the configuration file. The plan of a dry run is printed
+// to w.
+func runIndexer(ctx context.Context, job string, cfg webindexer.Config, w io.Writer) error {
 	indexer, err := webindexer.New(cfg)
 	if err != nil {
 		return fmt.Errorf("unable to create indexer: %w", err)
 	}
+	defer func() {
+		if err := indexer.Close(); err != nil {
+			log.Warnf("Unable to close connections: %v", err)
+		}
+	}()
+
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
 
 	log.Infof("Generating index for %s", cfg.Source)
-	err = indexer.Generate(nil, indexer.Cfg.BasePath)
-	if err != nil {
+	result, err := indexer.Generate(ctx, nil, indexer.Cfg.BasePath)
+	if cfg.Report != "" {
+		// The report is written even if generation failed, so that it shows
+		// how far it got
+		if reportErr := writeReport(cfg.Report, result); reportErr != nil {
+			return reportErr
+		}
+	}
+	if cfg.MetricsFile != "" {
+		if metricsErr := webindexer.WriteMetricsFile(cfg.MetricsFile, job, result, err, indexer.Cfg); metricsErr != nil {
+			return metricsErr
+		}
+	}
+
+	var failed *webindexer.GenerateError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("unable to generate index: timed out after %s", cfg.Timeout)
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("unable to generate index: interrupted")
+	case errors.As(err, &failed):
+		// The other directories were indexed, so report them before failing
+	case err != nil:
 		return fmt.Errorf("unable to generate index: %w", err)
 	}
 
+	if dryRun, ok := indexer.Target.(*webindexer.DryRunTarget); ok {
+		printPlan(w, dryRun.Planned())
+	} else {
+		log.Infof("Indexed %d directories with %d files (%d bytes) in %s",
+			result.Directories, result.Files, result.Bytes, result.Duration.Round(time.Millisecond))
+		log.Infof("Wrote %d index files, %d unchanged, %d skipped", result.Written, result.Unchanged, result.Skipped)
+		if cfg.Prune && failed == nil {
+			log.Infof("Pruned %d stale index files", result.Pruned)
+		}
+	}
+
+	if failed != nil {
+		return fmt.Errorf("unable to generate index: %w", failed)
+	}
+
+	return nil
+}
+
+// writeReport writes the result of a run to a JSON file.
+func writeReport(path string, result *webindexer.Result) error {
+	content, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode report: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(content, '\n'), 0o644); err != nil { // #nosec
+		return fmt.Errorf("unable to write report: %w", err)
+	}
+
 	return nil
 }
 
+// printPlan prints the index files a dry run would have written, followed by
+// their diffs if any were computed.
+func printPlan(w io.Writer, planned []webindexer.PlannedIndex) {
+	counts := map[webindexer.PlanStatus]int{}
+	for _, p := range planned {
+		counts[p.Status]++
+		fmt.Fprintf(w, "%-9s %s\n", p.Status, p.Path)
+	}
+
+	for _, p := range planned {
+		if p.Diff != "" {
+			fmt.Fprintf(w, "\n%s", p.Diff)
+		}
+	}
+
+	fmt.Fprintf(w, "\nDry run: %d to create, %d to update, %d unchanged",
+		counts[webindexer.PlanCreate], counts[webindexer.PlanUpdate], counts[webindexer.PlanUnchanged])
+	if counts[webindexer.PlanWrite] > 0 {
+		fmt.Fprintf(w, ", %d to write", counts[webindexer.PlanWrite])
+	}
+	if counts[webindexer.PlanDelete] > 0 {
+		fmt.Fprintf(w, ", %d to prune", counts[webindexer.PlanDelete])
+	}
+	fmt.Fprintln(w)
+}
+
 func initConfig(cfgFile *string) func() {
 	return func() {
 		if *cfgFile != "" {
@@ -125,30 +314,61 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVarP(&cfg.CfgFile, "config", "c", "", "config file")
 	rootCmd.Flags().StringVarP(&cfg.S3Endpoint, "s3-endpoint", "", "", "The S3 endpoint to use. Only needed for non-AWS S3 endpoints.")
+	rootCmd.Flags().StringVarP(&cfg.GCSEndpoint, "gcs-endpoint", "", "", "The GCS endpoint to use. Only needed for non-Google endpoints such as a fake GCS server.")
+	rootCmd.Flags().StringVarP(&cfg.AzureAccount, "azure-account", "", "", "The Azure storage account to use. Defaults to $AZURE_STORAGE_ACCOUNT.")
+	rootCmd.Flags().StringVarP(&cfg.AzureEndpoint, "azure-endpoint", "", "", "The Azure Blob Storage endpoint to use. Only needed for non-Azure endpoints such as Azurite.")
 	rootCmd.Flags().StringVarP(&cfg.BaseURL, "base-url", "u", "", "A URL to prepend to the links")
+	rootCmd.Flags().IntVarP(&cfg.Concurrency, "concurrency", "", 1, "The number of directories to index at once when indexing recursively")
 	rootCmd.Flags().StringVarP(&cfg.DateFormat, "date-format", "", "2006-01-02 15:04:05 MST", "The date format to use in the index page")
+	rootCmd.Flags().BoolVarP(&cfg.Diff, "diff", "", false, "Show a unified diff of each index file that would change. Requires --dry-run")
+	rootCmd.Flags().StringVarP(&cfg.DirConfigFile, "dir-config-file", "", webindexer.DefaultDirConfigFile, "The name of the configuration files that override settings for their directory and below. Empty to disable")
 	rootCmd.Flags().BoolVarP(&cfg.DirsFirst, "dirs-first", "", true, "List directories first")
+	rootCmd.Flags().BoolVarP(&cfg.DryRun, "dry-run", "", false, "Show which index files would be created, updated or unchanged without writing anything")
+	rootCmd.Flags().StringVarP(&cfg.Hidden, "hidden", "", "show", "How to list dotfiles and junk files such as .DS_Store and Thumbs.db. One of: show, hide, hide-except-allowlist")
+	rootCmd.Flags().StringSliceVarP(&cfg.HiddenAllow, "hidden-allow", "", []string{}, "Names or glob patterns of dotfiles and junk files to list with --hidden hide-except-allowlist. "+
+		"Comma separated or specified multiple times")
+	rootCmd.Flags().StringSliceVarP(&cfg.IgnoreFiles, "ignore-files", "", []string{".webindexerignore"}, "A list of files with gitignore patterns that hide entries in their directory and below. "+
+		"Comma separated or specified multiple times")
+	rootCmd.Flags().StringSliceVarP(&cfg.Includes, "include", "", []string{}, "Only list files matching these names or glob patterns, and directories with a matching file below them. "+
+		"Comma separated or specified multiple times")
+	rootCmd.Flags().BoolVarP(&cfg.Incremental, "incremental", "", false, "Only rewrite index files whose listing, configuration or template changed since the last run")
 	rootCmd.Flags().StringVarP(&cfg.IndexFile, "index-file", "i", "index.html", "The name of the index file")
+	rootCmd.Flags().StringSliceVarP(&jobNames, "job", "", []string{}, "Only run these jobs from the configuration file. "+
+		"Comma separated or specified multiple times")
+	rootCmd.Flags().StringSliceVarP(&cfg.JunkFiles, "junk-files", "", []string{}, "Names or glob patterns of junk files to hide in addition to the built-in list. "+
+		"Comma separated or specified multiple times")
+	rootCmd.Flags().BoolVarP(&cfg.KeepGoing, "keep-going", "", false, "Keep indexing other directories when one can't be read or written, and report the failures at the end")
 	rootCmd.Flags().BoolVarP(&cfg.LinkToIndexes, "link-to-index", "l", false, "Link to the index file or just the path")
 	rootCmd.Flags().BoolVarP(&cfg.LinkUpFromRoot, "link-up-from-root", "", false, "Show a parent/up link even when at the root of the indexed path")
 	rootCmd.Flags().StringVarP(&cfg.LinkUpText, "link-up-text", "", "Go Up", "Text to display for the up link from root")
 	rootCmd.Flags().StringVarP(&cfg.LinkUpURL, "link-up-url", "", "..", "URL path for the up link from root")
 	rootCmd.Flags().StringVarP(&cfg.LogLevel, "log-level", "L", "info", "The log level")
 	rootCmd.Flags().StringVarP(&cfg.LogFile, "log-file", "F", "", "The log file")
+	rootCmd.Flags().StringVarP(&cfg.ManifestFile, "manifest-file", "", webindexer.DefaultManifestFile, "The name of the manifest stored at the root of the target in incremental mode")
+	rootCmd.Flags().IntVarP(&cfg.MaxDepth, "max-depth", "", 0, "Only generate index files this many levels below the source when indexing recursively. Zero means no limit")
+	rootCmd.Flags().StringVarP(&cfg.MetricsFile, "metrics-file", "", "", "Write Prometheus metrics for the run to this file, e.g. for node_exporter's textfile collector")
 	rootCmd.Flags().BoolVarP(&cfg.Minify, "minify", "m", false, "Minify the index page")
 	rootCmd.Flags().StringSliceVarP(&cfg.NoIndexFiles, "noindex-files", "n", []string{".noindex"}, "A list of files that indicate a directory should be skipped. "+
 		"Comma separated or specified multiple times")
 	rootCmd.Flags().StringSliceVarP(&cfg.SkipIndexFiles, "skipindex-files", "", []string{".skipindex"}, "A list of files that indicate a directory should be skipped for indexing but still included in the parent directory listing. "+
 		"Comma separated or specified multiple times")
 	rootCmd.Flags().BoolVarP(&cfg.Quiet, "quiet", "q", false, "Suppress log output")
+	rootCmd.Flags().StringVarP(&cfg.SFTPKeyFile, "sftp-key-file", "", "", "A private key file to use for SFTP. Keys from the SSH agent are also used.")
+	rootCmd.Flags().StringVarP(&cfg.SFTPKnownHosts, "sftp-known-hosts", "", "", "The known_hosts file used to verify SFTP host keys (default ~/.ssh/known_hosts)")
 	rootCmd.Flags().StringVarP(&cfg.Order, "order", "", "asc", "The order for the items. One of: asc, desc")
+	rootCmd.Flags().BoolVarP(&cfg.ParallelJobs, "parallel-jobs", "", false, "Run the jobs from the configuration file at once instead of one at a time")
+	rootCmd.Flags().BoolVarP(&cfg.Prune, "prune", "", false, "Remove index files from target directories that are no longer indexed. Requires --recursive")
 	rootCmd.Flags().BoolVarP(&cfg.Recursive, "recursive", "r", false, "List files recursively")
-	rootCmd.Flags().StringSliceVarP(&cfg.Skips, "skip", "S", []string{}, "A list of files or directories to skip. "+
+	rootCmd.Flags().StringVarP(&cfg.Report, "report", "", "", "Write a JSON report of the run, with counts and per-directory timings, to this file")
+	rootCmd.Flags().StringSliceVarP(&cfg.Skips, "skip", "S", []string{}, "A list of files or directories to skip, as names or glob patterns such as *.tmp, **/node_modules or /private/**. "+
 		"Comma separated or specified multiple times")
 	rootCmd.Flags().StringVarP(&cfg.SortBy, "sort-by", "", "natural_name", "The order for the index page. One of: last_modified, name, natural_name")
-	rootCmd.Flags().StringVarP(&cfg.Source, "source", "s", "", "REQUIRED. The source directory or S3 URI to list")
-	rootCmd.Flags().StringVarP(&cfg.Target, "target", "t", "", "REQUIRED. The target directory or S3 URI to write to")
+	rootCmd.Flags().StringVarP(&cfg.Source, "source", "s", "", "REQUIRED. The source directory, archive file or S3, GCS, Azure, SFTP, HTTP(S) or git URI to list")
+	rootCmd.Flags().StringVarP(&cfg.Symlinks, "symlinks", "", "follow", "How to list symlinks in local sources. One of: follow, skip, show-as-link")
+	rootCmd.Flags().BoolVarP(&cfg.SymlinksInRoot, "symlinks-in-root", "", false, "Skip symlinks that point outside of the source when following symlinks")
+	rootCmd.Flags().StringVarP(&cfg.Target, "target", "t", "", "REQUIRED. The target directory or S3, GCS, Azure or SFTP URI to write to")
 	rootCmd.Flags().StringVarP(&cfg.Template, "template", "f", "", "A custom template file to use for the index page")
+	rootCmd.Flags().DurationVarP(&cfg.Timeout, "timeout", "", 0, "Stop indexing after this long, e.g. 10m. Zero means no timeout")
 	rootCmd.Flags().StringVarP(&cfg.Theme, "theme", "", "default", "The theme to use for the index page. One of: default, solarized, nord, dracula")
 	rootCmd.Flags().StringVarP(&cfg.Title, "title", "T", "", "The title of the index page")
 
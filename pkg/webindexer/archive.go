@@ -0,0 +1,179 @@
+package webindexer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ArchiveBackend is a read-only FileSource that exposes the directory tree
+// inside a zip or tar archive without extracting it.
+type ArchiveBackend struct {
+	path string
+	cfg  Config
+	tree *entryTree
+}
+
+var (
+	_ FileSource = &ArchiveBackend{}
+	_ FileReader = &ArchiveBackend{}
+)
+
+// archiveExtensions lists the supported archive file extensions.
+var archiveExtensions = []string{".zip", ".tar", ".tar.gz", ".tgz", ".tar.zst", ".tzst"}
+
+func isArchivePath(uri string) bool {
+	lower := strings.ToLower(uri)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// newArchiveBackend reads the archive's headers and builds its directory tree.
+// The ignore files and per-directory configuration files are read as well.
+func newArchiveBackend(archivePath string, cfg Config) (*ArchiveBackend, error) {
+	a := &ArchiveBackend{
+		path: archivePath,
+		cfg:  cfg,
+		tree: newEntryTree(),
+	}
+
+	log.Debugf("Reading archive %s", archivePath)
+
+	var err error
+	if strings.HasSuffix(strings.ToLower(archivePath), ".zip") {
+		err = a.readZip()
+	} else {
+		err = a.readTar()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read archive %s: %w", archivePath, err)
+	}
+
+	return a, nil
+}
+
+func (a *ArchiveBackend) readZip() error {
+	r, err := zip.OpenReader(a.path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		isDir := f.FileInfo().IsDir()
+		size := int64(f.UncompressedSize64)
+		if isDir {
+			size = 0
+		}
+		a.tree.add(f.Name, isDir, size, f.Modified)
+
+		if !isDir && sourceFile(a.cfg, path.Base(f.Name)) {
+			content, err := readZipFile(f)
+			if err != nil {
+				return err
+			}
+			a.tree.setContent(f.Name, content)
+		}
+	}
+
+	return nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+func (a *ArchiveBackend) readTar() error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	lower := strings.ToLower(a.path)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case strings.HasSuffix(lower, ".tar.zst"), strings.HasSuffix(lower, ".tzst"):
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			a.tree.add(hdr.Name, true, 0, hdr.ModTime)
+		case tar.TypeReg, tar.TypeSymlink, tar.TypeLink:
+			a.tree.add(hdr.Name, false, hdr.Size, hdr.ModTime)
+
+			if hdr.Typeflag == tar.TypeReg && sourceFile(a.cfg, path.Base(hdr.Name)) {
+				content, err := io.ReadAll(tr)
+				if err != nil {
+					return err
+				}
+				a.tree.setContent(hdr.Name, content)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *ArchiveBackend) Read(ctx context.Context, dir string) ([]*Item, bool, error) {
+	return a.tree.read(ctx, dir, a.cfg, "archive "+a.path)
+}
+
+// ReadFile returns the content of an ignore file or per-directory
+// configuration file in the archive.
+func (a *ArchiveBackend) ReadFile(_ context.Context, dir, name string) ([]byte, error) {
+	return a.tree.readFile(dir, name, "archive "+a.path)
+}
+
+// EnsureDirExists is not supported as archives are read-only.
+func (a *ArchiveBackend) EnsureDirExists(_ context.Context, relativePath string) error {
+	return fmt.Errorf("unable to create %s: %w", relativePath, ErrReadOnlySource)
+}
+
+// Write is not supported as archives are read-only.
+func (a *ArchiveBackend) Write(_ context.Context, data Data, _ string) error {
+	return fmt.Errorf("unable to write index for %s: %w", data.RelativePath, ErrReadOnlySource)
+}
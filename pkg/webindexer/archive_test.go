@@ -0,0 +1,233 @@
+package webindexer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var archiveModTime = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+// archiveFiles is the content of the test archives. Names ending in a slash
+// are directories. "docs/guide/" has no entry of its own.
+var archiveFiles = []struct {
+	name    string
+	content string
+}{
+	{"bin/", ""},
+	{"bin/tool", "#!/bin/sh\n"},
+	{"README.md", "hello"},
+	{"docs/guide/intro.md", "intro text"},
+	{"private/.noindex", ""},
+	{"private/secret.txt", "secret"},
+	{"site/.webindexerignore", "drafts/\n"},
+	{"site/" + DefaultDirConfigFile, "title: Site\n"},
+	{"site/drafts/todo.md", "todo"},
+	{"site/page.md", "page"},
+}
+
+func writeTarArchive(t *testing.T, w io.Writer) {
+	t.Helper()
+
+	tw := tar.NewWriter(w)
+	for _, f := range archiveFiles {
+		hdr := &tar.Header{
+			Name:    "./" + f.name,
+			Mode:    0o644,
+			Size:    int64(len(f.content)),
+			ModTime: archiveModTime,
+		}
+		if strings.HasSuffix(f.name, "/") {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0o755
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(f.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+}
+
+func createTestArchive(t *testing.T, name string) string {
+	t.Helper()
+
+	archivePath := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(archivePath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		zw := zip.NewWriter(f)
+		for _, file := range archiveFiles {
+			w, err := zw.CreateHeader(&zip.FileHeader{Name: file.name, Modified: archiveModTime})
+			require.NoError(t, err)
+			_, err = w.Write([]byte(file.content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+	case strings.HasSuffix(name, ".tar.gz"):
+		gw := gzip.NewWriter(f)
+		writeTarArchive(t, gw)
+		require.NoError(t, gw.Close())
+	case strings.HasSuffix(name, ".tar.zst"):
+		zw, err := zstd.NewWriter(f)
+		require.NoError(t, err)
+		writeTarArchive(t, zw)
+		require.NoError(t, zw.Close())
+	default:
+		writeTarArchive(t, f)
+	}
+
+	return archivePath
+}
+
+func TestArchiveBackendRead(t *testing.T) {
+	for _, name := range []string{"bundle.zip", "bundle.tar", "bundle.tar.gz", "bundle.tar.zst"} {
+		t.Run(name, func(t *testing.T) {
+			archivePath := createTestArchive(t, name)
+
+			backend, err := newArchiveBackend(archivePath, Config{
+				IndexFile:    "index.html",
+				NoIndexFiles: []string{".noindex"},
+			})
+			require.NoError(t, err)
+
+			items, hasNoIndex, err := backend.Read(t.Context(), "/")
+			require.NoError(t, err)
+			assert.False(t, hasNoIndex)
+
+			byName := map[string]*Item{}
+			for _, item := range items {
+				byName[item.Name] = item
+			}
+			require.Len(t, byName, 5)
+
+			require.Contains(t, byName, "README.md")
+			assert.Equal(t, int64(5), byName["README.md"].Size)
+			assert.True(t, byName["README.md"].LastModified.Equal(archiveModTime))
+			assert.True(t, byName["README.md"].HasMetadata)
+
+			require.Contains(t, byName, "bin")
+			assert.True(t, byName["bin"].IsDir)
+			assert.True(t, byName["bin"].HasMetadata)
+
+			// Implied directories are listed without metadata
+			require.Contains(t, byName, "docs")
+			assert.True(t, byName["docs"].IsDir)
+			assert.False(t, byName["docs"].HasMetadata)
+
+			// Directories with a noindex file are returned to be reported
+			require.Contains(t, byName, "private")
+			assert.True(t, byName["private"].NoIndex)
+			assert.False(t, byName["docs"].NoIndex)
+
+			items, _, err = backend.Read(t.Context(), "/docs/guide")
+			require.NoError(t, err)
+			require.Len(t, items, 1)
+			assert.Equal(t, "intro.md", items[0].Name)
+			assert.Equal(t, int64(10), items[0].Size)
+
+			_, hasNoIndex, err = backend.Read(t.Context(), "/private")
+			require.NoError(t, err)
+			assert.True(t, hasNoIndex)
+
+			_, _, err = backend.Read(t.Context(), "/missing")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestArchiveBackendReadFile(t *testing.T) {
+	for _, name := range []string{"bundle.zip", "bundle.tar.gz"} {
+		t.Run(name, func(t *testing.T) {
+			backend, err := newArchiveBackend(createTestArchive(t, name), Config{
+				IgnoreFiles:   []string{".webindexerignore"},
+				DirConfigFile: DefaultDirConfigFile,
+			})
+			require.NoError(t, err)
+
+			content, err := backend.ReadFile(t.Context(), "/site", ".webindexerignore")
+			require.NoError(t, err)
+			assert.Equal(t, "drafts/\n", string(content))
+
+			content, err = backend.ReadFile(t.Context(), "site", DefaultDirConfigFile)
+			require.NoError(t, err)
+			assert.Equal(t, "title: Site\n", string(content))
+
+			_, err = backend.ReadFile(t.Context(), "/", ".webindexerignore")
+			require.ErrorIs(t, err, fs.ErrNotExist)
+		})
+	}
+}
+
+func TestArchiveBackendIsReadOnly(t *testing.T) {
+	backend, err := newArchiveBackend(createTestArchive(t, "bundle.tar"), Config{})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, backend.Write(t.Context(), Data{RelativePath: "/"}, ""), ErrReadOnlySource)
+	require.ErrorIs(t, backend.EnsureDirExists(t.Context(), "/"), ErrReadOnlySource)
+}
+
+func TestIsArchivePath(t *testing.T) {
+	assert.True(t, isArchivePath("release.zip"))
+	assert.True(t, isArchivePath("/tmp/release.tar"))
+	assert.True(t, isArchivePath("release.TAR.GZ"))
+	assert.True(t, isArchivePath("release.tgz"))
+	assert.True(t, isArchivePath("release.tar.zst"))
+	assert.False(t, isArchivePath("/srv/releases"))
+	assert.False(t, isArchivePath("release.gz"))
+}
+
+func TestGenerateFromArchive(t *testing.T) {
+	archivePath := createTestArchive(t, "bundle.tar.gz")
+	targetDir := t.TempDir()
+
+	indexer, err := New(Config{
+		Source:        archivePath,
+		Target:        targetDir,
+		Recursive:     true,
+		IndexFile:     "index.html",
+		NoIndexFiles:  []string{".noindex"},
+		IgnoreFiles:   []string{".webindexerignore"},
+		DirConfigFile: DefaultDirConfigFile,
+		SortBy:        "name",
+		Order:         "asc",
+		Title:         "{source}{relativePath}",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/", indexer.Cfg.BasePath)
+
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+
+	root, err := os.ReadFile(filepath.Join(targetDir, "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(root), "bundle.tar.gz/")
+	assert.Contains(t, string(root), "README.md")
+
+	guide, err := os.ReadFile(filepath.Join(targetDir, "docs", "guide", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(guide), "intro.md")
+
+	assert.NoFileExists(t, filepath.Join(targetDir, "private", "index.html"))
+
+	site, err := os.ReadFile(filepath.Join(targetDir, "site", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(site), "<title>Site</title>")
+	assert.Contains(t, string(site), "page.md")
+	assert.NotContains(t, string(site), "drafts")
+	assert.NotContains(t, string(site), ".webindexerignore")
+	assert.NoDirExists(t, filepath.Join(targetDir, "site", "drafts"))
+}
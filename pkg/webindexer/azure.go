@@ -0,0 +1,270 @@
+package webindexer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/charmbracelet/log"
+)
+
+type AzureBackend struct {
+	svc       AzureAPI
+	container string
+	cfg       Config
+}
+
+// AzureAPI is the subset of Azure Blob Storage operations used by the
+// AzureBackend.
+type AzureAPI interface {
+	// ListBlobs returns every blob and virtual directory directly under the
+	// given prefix.
+	ListBlobs(ctx context.Context, containerName, prefix string) ([]*container.BlobItem, []*container.BlobPrefix, error)
+	// DownloadBlob returns the content of the blob, or an error wrapping
+	// fs.ErrNotExist if there is none.
+	DownloadBlob(ctx context.Context, containerName, blobName string) ([]byte, error)
+	UploadBlob(ctx context.Context, containerName, blobName, contentType string, content []byte) error
+}
+
+var (
+	_ FileSource = &AzureBackend{}
+	_ FileReader = &AzureBackend{}
+)
+
+// azureClient implements AzureAPI using the Azure Blob Storage client library.
+type azureClient struct {
+	client *azblob.Client
+}
+
+var _ AzureAPI = &azureClient{}
+
+// newAzureClient creates an Azure Blob Storage client.
+//
+// Credentials are taken from AZURE_STORAGE_CONNECTION_STRING if set, then from
+// AZURE_STORAGE_KEY as a shared key for the account, and otherwise from the
+// default Azure credential chain (environment, managed identity, Azure CLI).
+// The endpoint defaults to the public blob endpoint for the account.
+func newAzureClient(account, endpoint string) (*azureClient, error) {
+	if connStr := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); connStr != "" {
+		client, err := azblob.NewClientFromConnectionString(connStr, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &azureClient{client: client}, nil
+	}
+
+	if account == "" {
+		account = os.Getenv("AZURE_STORAGE_ACCOUNT")
+	}
+
+	if endpoint == "" {
+		if account == "" {
+			return nil, fmt.Errorf("an Azure storage account or endpoint is required")
+		}
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net/", account)
+	}
+
+	if key := os.Getenv("AZURE_STORAGE_KEY"); key != "" {
+		cred, err := azblob.NewSharedKeyCredential(account, key)
+		if err != nil {
+			return nil, err
+		}
+		client, err := azblob.NewClientWithSharedKeyCredential(endpoint, cred, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &azureClient{client: client}, nil
+	}
+
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+	client, err := azblob.NewClient(endpoint, cred, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &azureClient{client: client}, nil
+}
+
+func (c *azureClient) ListBlobs(
+	ctx context.Context,
+	containerName, prefix string,
+) ([]*container.BlobItem, []*container.BlobPrefix, error) {
+	var blobs []*container.BlobItem
+	var prefixes []*container.BlobPrefix
+
+	pager := c.client.ServiceClient().NewContainerClient(containerName).NewListBlobsHierarchyPager(
+		"/",
+		&container.ListBlobsHierarchyOptions{Prefix: to.Ptr(prefix)},
+	)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		blobs = append(blobs, page.Segment.BlobItems...)
+		prefixes = append(prefixes, page.Segment.BlobPrefixes...)
+	}
+
+	return blobs, prefixes, nil
+}
+
+func (c *azureClient) DownloadBlob(ctx context.Context, containerName, blobName string) ([]byte, error) {
+	resp, err := c.client.DownloadStream(ctx, containerName, blobName, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil, fmt.Errorf("az://%s/%s: %w", containerName, blobName, fs.ErrNotExist)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// UploadBlob uploads the blob. If the context is canceled before the upload
+// completes, the blob is not created or replaced.
+func (c *azureClient) UploadBlob(
+	ctx context.Context,
+	containerName, blobName, contentType string,
+	content []byte,
+) error {
+	_, err := c.client.UploadBuffer(ctx, containerName, blobName, content, &azblob.UploadBufferOptions{
+		HTTPHeaders: &blob.HTTPHeaders{
+			BlobContentType: to.Ptr(contentType),
+		},
+	})
+	return err
+}
+
+func (a *AzureBackend) Read(ctx context.Context, prefix string) ([]*Item, bool, error) {
+	// Ensure the prefix has a trailing slash for blob names
+	if !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
+	// Remove leading slash for blob names
+	prefix = strings.TrimPrefix(prefix, "/")
+
+	log.Debugf("Listing blobs in az://%s/%s", a.container, prefix)
+
+	blobs, prefixes, err := a.svc.ListBlobs(ctx, a.container, prefix)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to list Azure blobs: %w", err)
+	}
+
+	// First check for noindex files or skipindex files before processing anything else
+	noIndex, skipIndex := skipListing(a.cfg, "az://"+a.container+"/"+prefix, blobFileNames(blobs))
+	if noIndex {
+		return nil, true, nil
+	}
+	if skipIndex {
+		return []*Item{}, false, nil
+	}
+
+	var items []*Item
+	for _, b := range blobs {
+		name := strings.TrimPrefix(*b.Name, prefix)
+		if shouldSkip(name, itemPath(a.cfg, prefix, name), a.cfg.IndexFile, a.cfg.Skips) {
+			continue
+		}
+
+		item := &Item{
+			Name:  name,
+			IsDir: false,
+		}
+		if b.Properties != nil && b.Properties.ContentLength != nil && b.Properties.LastModified != nil {
+			item.Size = *b.Properties.ContentLength
+			item.LastModified = b.Properties.LastModified.Local()
+			item.HasMetadata = true
+		}
+
+		items = append(items, item)
+	}
+
+	for _, p := range prefixes {
+		log.Debugf("Found virtual directory: %s", *p.Name)
+
+		name := strings.TrimPrefix(*p.Name, prefix)
+		if matchPatterns(name, itemPath(a.cfg, prefix, name), a.cfg.Skips) {
+			continue
+		}
+
+		hasNoIndex, err := a.hasNoIndexFile(ctx, *p.Name)
+		if err != nil {
+			return nil, false, err
+		}
+
+		items = append(items, &Item{
+			Name:    name,
+			IsDir:   true,
+			NoIndex: hasNoIndex,
+		})
+	}
+
+	return items, false, nil
+}
+
+// hasNoIndexFile reports whether the given virtual directory directly contains
+// a noindex file.
+func (a *AzureBackend) hasNoIndexFile(ctx context.Context, prefix string) (bool, error) {
+	if len(a.cfg.NoIndexFiles) == 0 {
+		return false, nil
+	}
+
+	blobs, _, err := a.svc.ListBlobs(ctx, a.container, prefix)
+	if err != nil {
+		return false, fmt.Errorf("unable to list Azure blobs in prefix %s: %w", prefix, err)
+	}
+
+	return excludedDir(a.cfg, "az://"+a.container+"/"+prefix, blobFileNames(blobs)), nil
+}
+
+// blobFileNames returns the base names of the blobs in a listing.
+func blobFileNames(blobs []*container.BlobItem) []string {
+	names := make([]string, 0, len(blobs))
+	for _, b := range blobs {
+		names = append(names, filepath.Base(*b.Name))
+	}
+	return names
+}
+
+// ReadFile reads a blob from a virtual directory of the source.
+func (a *AzureBackend) ReadFile(ctx context.Context, prefix, name string) ([]byte, error) {
+	return a.svc.DownloadBlob(ctx, a.container, objectPrefix(prefix)+name)
+}
+
+// EnsureDirExists is a no-op for Azure as virtual directories are implicit.
+func (a *AzureBackend) EnsureDirExists(_ context.Context, relativePath string) error {
+	log.Debugf("EnsureDirExists called for Azure (no-op): az://%s/%s", a.container, relativePath)
+	return nil
+}
+
+func (a *AzureBackend) Write(ctx context.Context, data Data, content string) error {
+	containerName, target := uriToBucketAndPrefix(a.cfg.Target)
+	target = strings.TrimPrefix(target, a.cfg.BasePath)
+	target = filepath.Join(target, data.RelativePath, a.cfg.IndexFile)
+
+	// Blob names are relative to the container root
+	target = strings.TrimPrefix(target, "/")
+
+	log.Infof("Uploading %s to az://%s/%s", humanizeBytes(int64(len(content))), containerName, target)
+
+	return a.svc.UploadBlob(ctx, containerName, target, "text/html", []byte(content))
+}
+
+func isAzureURI(uri string) bool {
+	return strings.HasPrefix(uri, "az://")
+}
@@ -0,0 +1,202 @@
+package webindexer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAzureClient struct {
+	mock.Mock
+}
+
+func (m *MockAzureClient) ListBlobs(
+	_ context.Context,
+	containerName, prefix string,
+) ([]*container.BlobItem, []*container.BlobPrefix, error) {
+	args := m.Called(containerName, prefix)
+	return args.Get(0).([]*container.BlobItem), args.Get(1).([]*container.BlobPrefix), args.Error(2)
+}
+
+func (m *MockAzureClient) DownloadBlob(_ context.Context, containerName, blobName string) ([]byte, error) {
+	args := m.Called(containerName, blobName)
+	content, _ := args.Get(0).([]byte)
+	return content, args.Error(1)
+}
+
+func (m *MockAzureClient) UploadBlob(
+	_ context.Context,
+	containerName, blobName, contentType string,
+	content []byte,
+) error {
+	args := m.Called(containerName, blobName, contentType, string(content))
+	return args.Error(0)
+}
+
+func azureBlob(name string, size int64) *container.BlobItem {
+	return &container.BlobItem{
+		Name: to.Ptr(name),
+		Properties: &container.BlobProperties{
+			ContentLength: to.Ptr(size),
+			LastModified:  to.Ptr(time.Now()),
+		},
+	}
+}
+
+func TestAzureBackendRead(t *testing.T) {
+	mockSvc := new(MockAzureClient)
+	backend := AzureBackend{
+		svc:       mockSvc,
+		container: "test-container",
+		cfg: Config{
+			IndexFile:    "index.html",
+			NoIndexFiles: []string{".noindex"},
+		},
+	}
+
+	mockSvc.On("ListBlobs", "test-container", "prefix/").Return(
+		[]*container.BlobItem{
+			azureBlob("prefix/file1.txt", 1024),
+			azureBlob("prefix/index.html", 512),
+		},
+		[]*container.BlobPrefix{
+			{Name: to.Ptr("prefix/dir1/")},
+			{Name: to.Ptr("prefix/private/")},
+		},
+		nil,
+	)
+	mockSvc.On("ListBlobs", "test-container", "prefix/dir1/").Return(
+		[]*container.BlobItem{azureBlob("prefix/dir1/file2.txt", 2048)},
+		[]*container.BlobPrefix{},
+		nil,
+	)
+	mockSvc.On("ListBlobs", "test-container", "prefix/private/").Return(
+		[]*container.BlobItem{azureBlob("prefix/private/.noindex", 0)},
+		[]*container.BlobPrefix{},
+		nil,
+	)
+
+	items, hasNoIndex, err := backend.Read(t.Context(), "/prefix")
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+	require.Len(t, items, 3)
+
+	assert.Equal(t, "file1.txt", items[0].Name)
+	assert.Equal(t, int64(1024), items[0].Size)
+	assert.True(t, items[0].HasMetadata)
+	assert.False(t, items[0].IsDir)
+
+	assert.Equal(t, "dir1/", items[1].Name)
+	assert.True(t, items[1].IsDir)
+	assert.False(t, items[1].NoIndex)
+
+	assert.Equal(t, "private/", items[2].Name)
+	assert.True(t, items[2].NoIndex)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestAzureBackendReadWithNoIndex(t *testing.T) {
+	mockSvc := new(MockAzureClient)
+	backend := AzureBackend{
+		svc:       mockSvc,
+		container: "test-container",
+		cfg: Config{
+			NoIndexFiles: []string{".noindex"},
+		},
+	}
+
+	mockSvc.On("ListBlobs", "test-container", "prefix/").Return(
+		[]*container.BlobItem{
+			azureBlob("prefix/.noindex", 0),
+			azureBlob("prefix/file1.txt", 1024),
+		},
+		[]*container.BlobPrefix{},
+		nil,
+	)
+
+	items, hasNoIndex, err := backend.Read(t.Context(), "prefix/")
+	require.NoError(t, err)
+	assert.True(t, hasNoIndex)
+	assert.Empty(t, items)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestAzureBackendReadWithSkipIndex(t *testing.T) {
+	mockSvc := new(MockAzureClient)
+	backend := AzureBackend{
+		svc:       mockSvc,
+		container: "test-container",
+		cfg: Config{
+			SkipIndexFiles: []string{".skipindex"},
+		},
+	}
+
+	mockSvc.On("ListBlobs", "test-container", "prefix/").Return(
+		[]*container.BlobItem{
+			azureBlob("prefix/.skipindex", 0),
+			azureBlob("prefix/file1.txt", 1024),
+		},
+		[]*container.BlobPrefix{},
+		nil,
+	)
+
+	items, hasNoIndex, err := backend.Read(t.Context(), "prefix/")
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+	assert.Empty(t, items)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestAzureBackendWrite(t *testing.T) {
+	mockSvc := new(MockAzureClient)
+	backend := AzureBackend{
+		svc: mockSvc,
+		cfg: Config{
+			Target:    "az://test-container/site",
+			BasePath:  "/basepath",
+			IndexFile: "index.html",
+		},
+	}
+
+	content := "<html>Test Content</html>"
+	mockSvc.On("UploadBlob", "test-container", "site/subdir/index.html", "text/html", content).Return(nil)
+
+	err := backend.Write(t.Context(), Data{RelativePath: "/subdir"}, content)
+	require.NoError(t, err)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestNewAzureClient(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "")
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "")
+	t.Setenv("AZURE_STORAGE_KEY", "")
+
+	_, err := newAzureClient("", "")
+	assert.Error(t, err, "an account or endpoint is required")
+
+	// The well-known Azurite development account
+	t.Setenv(
+		"AZURE_STORAGE_KEY",
+		"Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==",
+	)
+	client, err := newAzureClient("devstoreaccount1", "http://127.0.0.1:10000/devstoreaccount1")
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:10000/devstoreaccount1", client.client.URL())
+}
+
+func TestIsAzureURI(t *testing.T) {
+	assert.True(t, isAzureURI("az://test-container/"))
+	assert.True(t, isAzureURI("az://test-container/one/two"))
+	assert.False(t, isAzureURI("s3://test-bucket/"))
+	assert.False(t, isAzureURI("/mnt/foo"))
+}
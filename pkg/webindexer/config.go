@@ -0,0 +1,181 @@
+package webindexer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+type Config struct {
+	BaseURL        string           `yaml:"base_url"          mapstructure:"base_url"`
+	Concurrency    int              `yaml:"concurrency"       mapstructure:"concurrency"`
+	DateFormat     string           `yaml:"date_format"       mapstructure:"date_format"`
+	Diff           bool             `yaml:"diff"              mapstructure:"diff"`
+	DirConfigFile  string           `yaml:"dir_config_file"   mapstructure:"dir_config_file"`
+	DirsFirst      bool             `yaml:"dirs_first"        mapstructure:"dirs_first"`
+	DryRun         bool             `yaml:"dry_run"           mapstructure:"dry_run"`
+	Hidden         string           `yaml:"hidden"            mapstructure:"hidden"`
+	HiddenAllow    []string         `yaml:"hidden_allow"      mapstructure:"hidden_allow"`
+	IgnoreFiles    []string         `yaml:"ignore_files"      mapstructure:"ignore_files"`
+	Includes       []string         `yaml:"includes"          mapstructure:"includes"`
+	Incremental    bool             `yaml:"incremental"       mapstructure:"incremental"`
+	IndexFile      string           `yaml:"index_file"        mapstructure:"index_file"`
+	Jobs           []map[string]any `yaml:"jobs"              mapstructure:"jobs"`
+	JunkFiles      []string         `yaml:"junk_files"        mapstructure:"junk_files"`
+	KeepGoing      bool             `yaml:"keep_going"        mapstructure:"keep_going"`
+	LinkToIndexes  bool             `yaml:"link_to_index"     mapstructure:"link_to_index"`
+	LinkUpFromRoot bool             `yaml:"link_up_from_root" mapstructure:"link_up_from_root"`
+	LinkUpText     string           `yaml:"link_up_text"      mapstructure:"link_up_text"`
+	LinkUpURL      string           `yaml:"link_up_url"       mapstructure:"link_up_url"`
+	LogLevel       string           `yaml:"log_level"         mapstructure:"log_level"`
+	LogFile        string           `yaml:"log_file"          mapstructure:"log_file"`
+	ManifestFile   string           `yaml:"manifest_file"     mapstructure:"manifest_file"`
+	MaxDepth       int              `yaml:"max_depth"         mapstructure:"max_depth"`
+	MetricsFile    string           `yaml:"metrics_file"      mapstructure:"metrics_file"`
+	Minify         bool             `yaml:"minify"            mapstructure:"minify"`
+	NoIndexFiles   []string         `yaml:"noindex_files"     mapstructure:"noindex_files"`
+	SkipIndexFiles []string         `yaml:"skipindex_files"   mapstructure:"skipindex_files"`
+	Order          string           `yaml:"order"             mapstructure:"order"`
+	ParallelJobs   bool             `yaml:"parallel_jobs"     mapstructure:"parallel_jobs"`
+	Prune          bool             `yaml:"prune"             mapstructure:"prune"`
+	Quiet          bool             `yaml:"quiet"             mapstructure:"quiet"`
+	Recursive      bool             `yaml:"recursive"         mapstructure:"recursive"`
+	Report         string           `yaml:"report"            mapstructure:"report"`
+	Skips          []string         `yaml:"skips"             mapstructure:"skips"`
+	SortBy         string           `yaml:"sort_by"           mapstructure:"sort_by"`
+	Source         string           `yaml:"source"            mapstructure:"source"`
+	Symlinks       string           `yaml:"symlinks"          mapstructure:"symlinks"`
+	SymlinksInRoot bool             `yaml:"symlinks_in_root"  mapstructure:"symlinks_in_root"`
+	Target         string           `yaml:"target"            mapstructure:"target"`
+	Template       string           `yaml:"template"          mapstructure:"template"`
+	Theme          string           `yaml:"theme"             mapstructure:"theme"`
+	Timeout        time.Duration    `yaml:"timeout"           mapstructure:"timeout"`
+	Title          string           `yaml:"title"             mapstructure:"title"`
+	CfgFile        string           `yaml:"-"`
+	BasePath       string           `yaml:"-"`
+	S3Endpoint     string           `yaml:"s3_endpoint"       mapstructure:"s3_endpoint"`
+	GCSEndpoint    string           `yaml:"gcs_endpoint"      mapstructure:"gcs_endpoint"`
+	AzureAccount   string           `yaml:"azure_account"     mapstructure:"azure_account"`
+	AzureEndpoint  string           `yaml:"azure_endpoint"    mapstructure:"azure_endpoint"`
+	SFTPKeyFile    string           `yaml:"sftp_key_file"     mapstructure:"sftp_key_file"`
+	SFTPKnownHosts string           `yaml:"sftp_known_hosts"  mapstructure:"sftp_known_hosts"`
+}
+
+type SortBy string
+
+const (
+	SortByDate        SortBy = "date"
+	SortByName        SortBy = "name"
+	SortByNaturalName SortBy = "natural_name"
+)
+
+type Order string
+
+const (
+	OrderAsc  Order = "asc"
+	OrderDesc Order = "desc"
+)
+
+type Theme string
+
+const (
+	ThemeDefault   Theme = "default"
+	ThemeSolarized Theme = "solarized"
+	ThemeNord      Theme = "nord"
+	ThemeDracula   Theme = "dracula"
+)
+
+func (c Config) SortByValue() SortBy {
+	switch c.SortBy {
+	case "last_modified":
+		return SortByDate
+	case "name":
+		return SortByName
+	case "natural_name":
+		return SortByNaturalName
+	default:
+		return ""
+	}
+}
+
+func (c Config) OrderByValue() Order {
+	switch c.Order {
+	case "asc":
+		return OrderAsc
+	case "desc":
+		return OrderDesc
+	default:
+		return ""
+	}
+}
+
+func (c Config) ThemeValue() Theme {
+	switch c.Theme {
+	case "solarized":
+		return ThemeSolarized
+	case "nord":
+		return ThemeNord
+	case "dracula":
+		return ThemeDracula
+	default:
+		return ThemeDefault
+	}
+}
+
+func (c Config) Validate() error {
+	if c.Source == "" {
+		return fmt.Errorf("source is required")
+	}
+
+	if c.Target == "" {
+		return fmt.Errorf("target is required")
+	}
+
+	if c.SortByValue() == "" {
+		return fmt.Errorf("sort_by must be one of: last_modified, name, natural_name")
+	}
+
+	if c.OrderByValue() == "" {
+		return fmt.Errorf("order must be one of: asc, desc")
+	}
+
+	if c.SymlinksValue() == "" {
+		return fmt.Errorf("symlinks must be one of: follow, skip, show-as-link")
+	}
+
+	if c.HiddenValue() == "" {
+		return fmt.Errorf("hidden must be one of: show, hide, hide-except-allowlist")
+	}
+
+	if c.Concurrency < 0 {
+		return fmt.Errorf("concurrency must not be negative")
+	}
+
+	if c.MaxDepth < 0 {
+		return fmt.Errorf("max_depth must not be negative")
+	}
+
+	if c.Diff && !c.DryRun {
+		return fmt.Errorf("diff can only be used with dry_run")
+	}
+
+	if c.Prune && !c.Recursive {
+		return fmt.Errorf("prune can only be used with recursive")
+	}
+
+	for _, pattern := range c.Skips {
+		if !doublestar.ValidatePattern(strings.TrimPrefix(pattern, "/")) {
+			return fmt.Errorf("invalid skip pattern %q", pattern)
+		}
+	}
+
+	for _, pattern := range c.Includes {
+		if !doublestar.ValidatePattern(strings.TrimPrefix(pattern, "/")) {
+			return fmt.Errorf("invalid include pattern %q", pattern)
+		}
+	}
+
+	return nil
+}
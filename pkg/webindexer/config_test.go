@@ -0,0 +1,159 @@
+package webindexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "valid config",
+			config: Config{
+				Source: "some/source/path",
+				Target: "some/target/path",
+				SortBy: "name",
+				Order:  "asc",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "missing source",
+			config:  Config{Target: "some/target/path", SortBy: "name", Order: "asc"},
+			wantErr: true,
+			errMsg:  "source is required",
+		},
+		{
+			name:    "missing target",
+			config:  Config{Source: "some/source/path", SortBy: "name", Order: "asc"},
+			wantErr: true,
+			errMsg:  "target is required",
+		},
+		{
+			name:    "invalid sort_by",
+			config:  Config{Source: "some/source/path", Target: "some/target/path", SortBy: "invalid", Order: "asc"},
+			wantErr: true,
+			errMsg:  "sort_by must be one of: last_modified, name, natural_name",
+		},
+		{
+			name:    "invalid order",
+			config:  Config{Source: "some/source/path", Target: "some/target/path", SortBy: "name", Order: "invalid"},
+			wantErr: true,
+			errMsg:  "order must be one of: asc, desc",
+		},
+		{
+			name: "negative concurrency",
+			config: Config{
+				Source:      "some/source/path",
+				Target:      "some/target/path",
+				SortBy:      "name",
+				Order:       "asc",
+				Concurrency: -1,
+			},
+			wantErr: true,
+			errMsg:  "concurrency must not be negative",
+		},
+		{
+			name: "negative max depth",
+			config: Config{
+				Source:   "some/source/path",
+				Target:   "some/target/path",
+				SortBy:   "name",
+				Order:    "asc",
+				MaxDepth: -1,
+			},
+			wantErr: true,
+			errMsg:  "max_depth must not be negative",
+		},
+		{
+			name: "diff without dry run",
+			config: Config{
+				Source: "some/source/path",
+				Target: "some/target/path",
+				SortBy: "name",
+				Order:  "asc",
+				Diff:   true,
+			},
+			wantErr: true,
+			errMsg:  "diff can only be used with dry_run",
+		},
+		{
+			name: "prune without recursive",
+			config: Config{
+				Source: "some/source/path",
+				Target: "some/target/path",
+				SortBy: "name",
+				Order:  "asc",
+				Prune:  true,
+			},
+			wantErr: true,
+			errMsg:  "prune can only be used with recursive",
+		},
+		{
+			name: "invalid skip pattern",
+			config: Config{
+				Source: "some/source/path",
+				Target: "some/target/path",
+				SortBy: "name",
+				Order:  "asc",
+				Skips:  []string{"*.tmp", "[abc"},
+			},
+			wantErr: true,
+			errMsg:  `invalid skip pattern "[abc"`,
+		},
+		{
+			name: "invalid symlinks policy",
+			config: Config{
+				Source:   "some/source/path",
+				Target:   "some/target/path",
+				SortBy:   "name",
+				Order:    "asc",
+				Symlinks: "ignore",
+			},
+			wantErr: true,
+			errMsg:  "symlinks must be one of: follow, skip, show-as-link",
+		},
+		{
+			name: "invalid hidden policy",
+			config: Config{
+				Source: "some/source/path",
+				Target: "some/target/path",
+				SortBy: "name",
+				Order:  "asc",
+				Hidden: "none",
+			},
+			wantErr: true,
+			errMsg:  "hidden must be one of: show, hide, hide-except-allowlist",
+		},
+		{
+			name: "invalid include pattern",
+			config: Config{
+				Source:   "some/source/path",
+				Target:   "some/target/path",
+				SortBy:   "name",
+				Order:    "asc",
+				Includes: []string{"[abc"},
+			},
+			wantErr: true,
+			errMsg:  `invalid include pattern "[abc"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package webindexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"github.com/charmbracelet/log"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDirConfigFile is the name of the per-directory configuration file.
+// It differs from the main configuration file, which would otherwise be applied
+// again from the root of the source over the command line flags.
+const DefaultDirConfigFile = ".web-indexer-dir.yml"
+
+// DirConfig holds the settings a per-directory configuration file can
+// override for its directory and everything below it. Settings that aren't
+// set are inherited from the parent directory. Skip patterns are added to the
+// inherited ones. Other keys are ignored.
+type DirConfig struct {
+	DateFormat *string  `yaml:"date_format"`
+	DirsFirst  *bool    `yaml:"dirs_first"`
+	Order      *string  `yaml:"order"`
+	Skips      []string `yaml:"skips"`
+	SortBy     *string  `yaml:"sort_by"`
+	Theme      *string  `yaml:"theme"`
+	Title      *string  `yaml:"title"`
+}
+
+// Apply returns cfg with the settings of the directory configuration merged
+// over it.
+func (d DirConfig) Apply(cfg Config) Config {
+	if d.DateFormat != nil {
+		cfg.DateFormat = *d.DateFormat
+	}
+	if d.DirsFirst != nil {
+		cfg.DirsFirst = *d.DirsFirst
+	}
+	if d.Order != nil {
+		cfg.Order = *d.Order
+	}
+	if len(d.Skips) > 0 {
+		cfg.Skips = append(append([]string{}, cfg.Skips...), d.Skips...)
+	}
+	if d.SortBy != nil {
+		cfg.SortBy = *d.SortBy
+	}
+	if d.Theme != nil {
+		cfg.Theme = *d.Theme
+	}
+	if d.Title != nil {
+		cfg.Title = *d.Title
+	}
+	return cfg
+}
+
+// dirConfig returns the configuration for a directory, with its configuration
+// file merged over the configuration inherited from its parent. The items of
+// the directory are filtered again, as the source only applied the skip
+// patterns of the main configuration.
+func (i Indexer) dirConfig(ctx context.Context, path string, items []*Item) (Config, []*Item, error) {
+	if i.Cfg.DirConfigFile == "" {
+		return i.Cfg, items, nil
+	}
+
+	// The file is only read when the listing has it
+	present := slices.ContainsFunc(items, func(item *Item) bool {
+		return !item.IsDir && item.Name == i.Cfg.DirConfigFile
+	})
+	if !present {
+		return i.Cfg, i.skipItems(path, items), nil
+	}
+
+	content, err := i.readSourceFile(ctx, path, i.Cfg.DirConfigFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return i.Cfg, i.skipItems(path, items), nil
+	}
+	if err != nil {
+		return i.Cfg, nil, fmt.Errorf("unable to read %s in %s: %w", i.Cfg.DirConfigFile, path, err)
+	}
+
+	var dirCfg DirConfig
+	if err := yaml.Unmarshal(content, &dirCfg); err != nil {
+		return i.Cfg, nil, fmt.Errorf("invalid %s in %s: %w", i.Cfg.DirConfigFile, path, err)
+	}
+
+	cfg := dirCfg.Apply(i.Cfg)
+	if err := cfg.Validate(); err != nil {
+		return i.Cfg, nil, fmt.Errorf("invalid %s in %s: %w", i.Cfg.DirConfigFile, path, err)
+	}
+	log.Debugf("Using %s for %s", i.Cfg.DirConfigFile, path)
+
+	i.Cfg = cfg
+	return cfg, i.skipItems(path, items), nil
+}
+
+// skipItems removes the items of a directory that match the skip patterns.
+func (i Indexer) skipItems(path string, items []*Item) []*Item {
+	kept := items[:0]
+	for _, item := range items {
+		if !matchPatterns(item.Name, itemPath(i.Cfg, path, item.Name), i.Cfg.Skips) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
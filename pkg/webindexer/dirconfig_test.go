@@ -0,0 +1,216 @@
+package webindexer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirConfigApply(t *testing.T) {
+	title := "Releases"
+	order := "desc"
+	dirsFirst := false
+	cfg := DirConfig{
+		Title:     &title,
+		Order:     &order,
+		DirsFirst: &dirsFirst,
+		Skips:     []string{"*.tmp"},
+	}.Apply(Config{
+		Title:     "Files",
+		SortBy:    "name",
+		Order:     "asc",
+		DirsFirst: true,
+		Skips:     []string{".git"},
+	})
+
+	assert.Equal(t, "Releases", cfg.Title)
+	assert.Equal(t, "name", cfg.SortBy)
+	assert.Equal(t, "desc", cfg.Order)
+	assert.False(t, cfg.DirsFirst)
+	assert.Equal(t, []string{".git", "*.tmp"}, cfg.Skips)
+}
+
+func TestGenerate_DirConfig(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := t.TempDir()
+	files := map[string]string{
+		"readme.txt":                       "",
+		"releases/" + DefaultDirConfigFile: "title: Releases\norder: desc\nskips: ['*.tmp']\n",
+		"releases/a-1.0.tar.gz":            "",
+		"releases/b-2.0.tar.gz":            "",
+		"releases/build.tmp":               "",
+		"releases/nightly/c.tar.gz":        "",
+		"releases/nightly/partial.tmp":     "",
+		"docs/" + DefaultDirConfigFile:     "theme: nord\n# Other keys are ignored\nsource: /elsewhere\n",
+		"docs/guide.txt":                   "",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(sourceDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0o644))
+	}
+
+	indexer, err := New(Config{
+		Source:        sourceDir,
+		Target:        targetDir,
+		Recursive:     true,
+		DirConfigFile: DefaultDirConfigFile,
+		IndexFile:     "index.html",
+		Title:         "Files",
+		SortBy:        "name",
+		Order:         "asc",
+		DateFormat:    "2006-01-02 15:04:05 MST",
+	})
+	require.NoError(t, err)
+
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+
+	tree := readTree(t, targetDir)
+	assert.Contains(t, tree["index.html"], "<title>Files</title>")
+
+	releases := tree[filepath.Join("releases", "index.html")]
+	assert.Contains(t, releases, "<title>Releases</title>")
+	assert.NotContains(t, releases, DefaultDirConfigFile)
+	assert.NotContains(t, releases, "build.tmp")
+	assert.Less(t, strings.Index(releases, "b-2.0.tar.gz"), strings.Index(releases, "a-1.0.tar.gz"))
+
+	// Subdirectories inherit the configuration
+	nightly := tree[filepath.Join("releases", "nightly", "index.html")]
+	assert.Contains(t, nightly, "<title>Releases</title>")
+	assert.NotContains(t, nightly, "partial.tmp")
+
+	docs := tree[filepath.Join("docs", "index.html")]
+	assert.Contains(t, docs, "<title>Files</title>")
+	assert.Contains(t, docs, "guide.txt")
+	assert.NotEqual(t, themeStyle(t, tree["index.html"]), themeStyle(t, docs))
+}
+
+func TestGenerate_DirConfigMainConfigFile(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := t.TempDir()
+	// The main configuration file in the root of the source isn't applied
+	// again over the settings given on the command line
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, ".web-indexer.yml"), []byte("title: From file\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "readme.txt"), nil, 0o644))
+
+	indexer, err := New(Config{
+		Source:        sourceDir,
+		Target:        targetDir,
+		Recursive:     true,
+		DirConfigFile: DefaultDirConfigFile,
+		IndexFile:     "index.html",
+		Title:         "From CLI",
+		SortBy:        "name",
+		Order:         "asc",
+	})
+	require.NoError(t, err)
+
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+
+	root, err := os.ReadFile(filepath.Join(targetDir, "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(root), "<title>From CLI</title>")
+}
+
+func TestGenerate_DirConfigInvalid(t *testing.T) {
+	sourceDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "docs"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "docs", "guide.txt"), nil, 0o644))
+	configFile := filepath.Join(sourceDir, "docs", DefaultDirConfigFile)
+	require.NoError(t, os.WriteFile(configFile, []byte("sort_by: size\n"), 0o644))
+
+	indexer, err := New(Config{
+		Source:        sourceDir,
+		Target:        t.TempDir(),
+		Recursive:     true,
+		DirConfigFile: DefaultDirConfigFile,
+		IndexFile:     "index.html",
+		SortBy:        "name",
+		Order:         "asc",
+	})
+	require.NoError(t, err)
+
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	var dirErr *DirError
+	require.ErrorAs(t, err, &dirErr)
+	assert.Equal(t, "/docs", dirErr.Path)
+	assert.Contains(t, err.Error(), "sort_by must be one of")
+}
+
+func TestS3BackendReadDirConfig(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	backend := &S3Backend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			Source:        "s3://test-bucket",
+			Target:        "s3://test-bucket",
+			IndexFile:     "index.html",
+			DirConfigFile: DefaultDirConfigFile,
+			SortBy:        "name",
+			Order:         "asc",
+		},
+	}
+
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+		return *input.Prefix == "docs/"
+	})).Return(&s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			{Key: aws.String("docs/" + DefaultDirConfigFile), Size: aws.Int64(1), LastModified: aws.Time(time.Now())},
+			{Key: aws.String("docs/guide.txt"), Size: aws.Int64(1), LastModified: aws.Time(time.Now())},
+		},
+	}, nil)
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+		return *input.Prefix == "other/"
+	})).Return(&s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			{Key: aws.String("other/file.txt"), Size: aws.Int64(1), LastModified: aws.Time(time.Now())},
+		},
+	}, nil)
+	mockSvc.On("GetObjectWithContext", mock.MatchedBy(func(input *s3.GetObjectInput) bool {
+		return *input.Key == "docs/"+DefaultDirConfigFile
+	})).Return(&s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader("title: Docs\n"))}, nil).Once()
+
+	indexer := Indexer{Cfg: backend.cfg, Source: backend}
+	readDir := func(dir string) (Config, []string) {
+		items, _, err := backend.Read(t.Context(), dir)
+		require.NoError(t, err)
+		cfg, items, err := indexer.dirConfig(t.Context(), dir, items)
+		require.NoError(t, err)
+		items, err = indexer.filterItems(t.Context(), dir, items)
+		require.NoError(t, err)
+		return cfg, itemNames(items)
+	}
+
+	cfg, names := readDir("/docs")
+	assert.Equal(t, "Docs", cfg.Title)
+	assert.Equal(t, []string{"guide.txt"}, names)
+
+	// The listing shows there's no configuration file, so it isn't fetched
+	cfg, names = readDir("other/")
+	assert.Empty(t, cfg.Title)
+	assert.Equal(t, []string{"file.txt"}, names)
+
+	mockSvc.AssertExpectations(t)
+}
+
+// themeStyle returns the stylesheet of a generated index.
+func themeStyle(t *testing.T, index string) string {
+	t.Helper()
+
+	start := strings.Index(index, "<style>")
+	end := strings.Index(index, "</style>")
+	require.True(t, start >= 0 && end > start, "index has no stylesheet")
+	return index[start:end]
+}
@@ -0,0 +1,188 @@
+package webindexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/charmbracelet/log"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// IndexReader is implemented by targets that can read back an existing index
+// file. ReadIndex returns an error wrapping fs.ErrNotExist if there is none.
+type IndexReader interface {
+	ReadIndex(ctx context.Context, data Data) ([]byte, error)
+}
+
+// PlanStatus describes what a dry run found for an index file.
+type PlanStatus string
+
+const (
+	// PlanCreate means the index file doesn't exist at the target yet.
+	PlanCreate PlanStatus = "create"
+	// PlanUpdate means the index file exists with different content.
+	PlanUpdate PlanStatus = "update"
+	// PlanUnchanged means the index file already has the generated content.
+	PlanUnchanged PlanStatus = "unchanged"
+	// PlanWrite means the index file would be written, but the target can't
+	// read it back to tell whether it changed.
+	PlanWrite PlanStatus = "write"
+	// PlanDelete means the index file is stale and would be pruned.
+	PlanDelete PlanStatus = "delete"
+)
+
+// PlannedIndex is an index file that a dry run would have written or pruned.
+type PlannedIndex struct {
+	// Path is the index file's path relative to the target.
+	Path   string
+	Status PlanStatus
+	// Diff is a unified diff of the existing and generated content when
+	// Config.Diff is set.
+	Diff string
+}
+
+// DryRunTarget wraps a target, recording the index files that would be
+// written instead of writing them.
+type DryRunTarget struct {
+	target FileSource
+	cfg    Config
+
+	mu      sync.Mutex
+	planned []PlannedIndex
+}
+
+var (
+	_ FileSource    = &DryRunTarget{}
+	_ ManifestStore = &DryRunTarget{}
+	_ IndexPruner   = &DryRunTarget{}
+	_ IndexReader   = &DryRunTarget{}
+)
+
+func newDryRunTarget(target FileSource, cfg Config) *DryRunTarget {
+	return &DryRunTarget{target: target, cfg: cfg}
+}
+
+// Read lists the wrapped target.
+func (d *DryRunTarget) Read(ctx context.Context, dir string) ([]*Item, bool, error) {
+	return d.target.Read(ctx, dir)
+}
+
+// EnsureDirExists does nothing, as no directories are created in a dry run.
+func (d *DryRunTarget) EnsureDirExists(_ context.Context, relativePath string) error {
+	log.Debugf("Dry run: not creating %s", relativePath)
+	return nil
+}
+
+// Write compares the content with the existing index file, if the target can
+// read it, and records the result.
+func (d *DryRunTarget) Write(ctx context.Context, data Data, content string) error {
+	planned := PlannedIndex{
+		Path:   path.Join("/", data.RelativePath, d.cfg.IndexFile),
+		Status: PlanWrite,
+	}
+
+	if reader, ok := d.target.(IndexReader); ok {
+		existing, err := reader.ReadIndex(ctx, data)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			planned.Status = PlanCreate
+		case err != nil:
+			return fmt.Errorf("unable to read existing index for %s: %w", data.RelativePath, err)
+		case string(existing) == content:
+			planned.Status = PlanUnchanged
+		default:
+			planned.Status = PlanUpdate
+		}
+
+		if d.cfg.Diff && planned.Status != PlanUnchanged {
+			planned.Diff, err = unifiedDiff(planned.Path, string(existing), content)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Debugf("Dry run: %s %s", planned.Status, planned.Path)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.planned = append(d.planned, planned)
+
+	return nil
+}
+
+// ReadManifest reads the manifest from the wrapped target, so that a dry run
+// in incremental mode plans the same changes as a real run.
+func (d *DryRunTarget) ReadManifest(ctx context.Context, name string) ([]byte, error) {
+	store, ok := d.target.(ManifestStore)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	return store.ReadManifest(ctx, name)
+}
+
+// WriteManifest does nothing, as the target is left untouched in a dry run.
+func (d *DryRunTarget) WriteManifest(_ context.Context, name string, _ []byte) error {
+	log.Debugf("Dry run: not writing manifest %s", name)
+	return nil
+}
+
+// ReadIndex reads the existing index file from the wrapped target.
+func (d *DryRunTarget) ReadIndex(ctx context.Context, data Data) ([]byte, error) {
+	reader, ok := d.target.(IndexReader)
+	if !ok {
+		return nil, fmt.Errorf("reading index files is not supported for target %s", d.cfg.Target)
+	}
+	return reader.ReadIndex(ctx, data)
+}
+
+// ListIndexes lists the index files of the wrapped target.
+func (d *DryRunTarget) ListIndexes(ctx context.Context) ([]string, error) {
+	pruner, ok := d.target.(IndexPruner)
+	if !ok {
+		return nil, fmt.Errorf("pruning is not supported for target %s", d.cfg.Target)
+	}
+	return pruner.ListIndexes(ctx)
+}
+
+// DeleteIndex records that the index file would be pruned.
+func (d *DryRunTarget) DeleteIndex(_ context.Context, dir string) error {
+	planned := PlannedIndex{
+		Path:   path.Join(dir, d.cfg.IndexFile),
+		Status: PlanDelete,
+	}
+	log.Debugf("Dry run: %s %s", planned.Status, planned.Path)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.planned = append(d.planned, planned)
+
+	return nil
+}
+
+// Planned returns the recorded index files sorted by path.
+func (d *DryRunTarget) Planned() []PlannedIndex {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	planned := make([]PlannedIndex, len(d.planned))
+	copy(planned, d.planned)
+	sort.Slice(planned, func(a, b int) bool { return planned[a].Path < planned[b].Path })
+
+	return planned
+}
+
+func unifiedDiff(name, existing, generated string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(existing),
+		B:        difflib.SplitLines(generated),
+		FromFile: "a" + name,
+		ToFile:   "b" + name,
+		Context:  3,
+	})
+}
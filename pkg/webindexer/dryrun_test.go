@@ -0,0 +1,78 @@
+package webindexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_DryRun(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "file.txt"), []byte("content"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "sub", "file.txt"), []byte("content"), 0o644))
+
+	cfg := Config{
+		Source:     sourceDir,
+		Target:     targetDir,
+		Recursive:  true,
+		DryRun:     true,
+		Diff:       true,
+		IndexFile:  "index.html",
+		SortBy:     "name",
+		Order:      "asc",
+		DateFormat: "2006-01-02 15:04:05 MST",
+	}
+
+	// Nothing exists at the target yet
+	indexer, err := New(cfg)
+	require.NoError(t, err)
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+
+	dryRun, ok := indexer.Target.(*DryRunTarget)
+	require.True(t, ok)
+	planned := dryRun.Planned()
+	require.Len(t, planned, 2)
+	assert.Equal(t, "/index.html", planned[0].Path)
+	assert.Equal(t, PlanCreate, planned[0].Status)
+	assert.Equal(t, "/sub/index.html", planned[1].Path)
+	assert.Equal(t, PlanCreate, planned[1].Status)
+	assert.Contains(t, planned[1].Diff, "+++ b/sub/index.html")
+	assert.Empty(t, readTree(t, targetDir), "a dry run must not write to the target")
+
+	// Diff can only be used with a dry run
+	cfg.DryRun, cfg.Diff = false, false
+	indexer, err = New(cfg)
+	require.NoError(t, err)
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+	before := readTree(t, targetDir)
+
+	// An old, empty file changes the listing of sub without changing the size
+	// or modification time shown for it in the root listing
+	newFile := filepath.Join(sourceDir, "sub", "new.txt")
+	require.NoError(t, os.WriteFile(newFile, nil, 0o644))
+	old := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(newFile, old, old))
+
+	cfg.DryRun, cfg.Diff = true, true
+	indexer, err = New(cfg)
+	require.NoError(t, err)
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+
+	planned = indexer.Target.(*DryRunTarget).Planned()
+	require.Len(t, planned, 2)
+	assert.Equal(t, PlanUnchanged, planned[0].Status)
+	assert.Empty(t, planned[0].Diff)
+	assert.Equal(t, PlanUpdate, planned[1].Status)
+	assert.Contains(t, planned[1].Diff, "--- a/sub/index.html")
+	assert.Contains(t, planned[1].Diff, "new.txt")
+	assert.Equal(t, before, readTree(t, targetDir))
+}
@@ -0,0 +1,85 @@
+package webindexer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/log"
+)
+
+// DirError is a failure to read or write the index of a single directory.
+type DirError struct {
+	// Path is the directory relative to the source.
+	Path string
+	// Op is "read" or "write".
+	Op  string
+	Err error
+}
+
+func (e *DirError) Error() string {
+	return fmt.Sprintf("unable to %s %s: %v", e.Op, e.Path, e.Err)
+}
+
+func (e *DirError) Unwrap() error {
+	return e.Err
+}
+
+// GenerateError is returned by Generate in keep-going mode when any
+// directory failed. The other directories were still indexed.
+type GenerateError struct {
+	// Failures are sorted by path.
+	Failures []*DirError
+}
+
+func (e *GenerateError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "failed to index %d directories:", len(e.Failures))
+	for _, failure := range e.Failures {
+		fmt.Fprintf(&b, "\n  %s", failure)
+	}
+	return b.String()
+}
+
+func (e *GenerateError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for n, failure := range e.Failures {
+		errs[n] = failure
+	}
+	return errs
+}
+
+// failures collects the directories that failed in keep-going mode.
+type failures struct {
+	mu   sync.Mutex
+	errs []*DirError
+}
+
+func (f *failures) add(err *DirError) {
+	log.Errorf("Error generating index: %v", err)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs = append(f.errs, err)
+}
+
+// err returns a GenerateError if any directory failed.
+func (f *failures) err() error {
+	if f == nil {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.errs) == 0 {
+		return nil
+	}
+
+	errs := make([]*DirError, len(f.errs))
+	copy(errs, f.errs)
+	sort.SliceStable(errs, func(a, b int) bool { return errs[a].Path < errs[b].Path })
+
+	return &GenerateError{Failures: errs}
+}
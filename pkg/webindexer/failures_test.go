@@ -0,0 +1,110 @@
+package webindexer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_KeepGoing(t *testing.T) {
+	fsys := newConcurrencyTestFS()
+
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			source := &countingSource{
+				FileSource: NewFSSource(fsys, Config{IndexFile: "index.html"}),
+				fail:       map[string]bool{"/dir4": true, "/dir1/sub2": true},
+			}
+
+			targetDir := t.TempDir()
+			// A stale index that must survive, as nothing is pruned after a
+			// failure
+			require.NoError(t, os.MkdirAll(filepath.Join(targetDir, "stale"), 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(targetDir, "stale", "index.html"), nil, 0o644))
+
+			indexer, err := NewWithSource(Config{
+				Target:      targetDir,
+				Recursive:   true,
+				KeepGoing:   true,
+				Prune:       true,
+				IndexFile:   "index.html",
+				SortBy:      "name",
+				Order:       "asc",
+				DateFormat:  "2006-01-02 15:04:05 MST",
+				Concurrency: concurrency,
+			}, source)
+			require.NoError(t, err)
+
+			_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+			var failed *GenerateError
+			require.ErrorAs(t, err, &failed)
+			require.Len(t, failed.Failures, 2)
+			assert.Equal(t, "/dir1/sub2", failed.Failures[0].Path)
+			assert.Equal(t, "read", failed.Failures[0].Op)
+			assert.Equal(t, "/dir4", failed.Failures[1].Path)
+			assert.Contains(t, err.Error(), "failed to index 2 directories")
+
+			var dirErr *DirError
+			require.ErrorAs(t, err, &dirErr)
+			assert.Equal(t, "/dir1/sub2", dirErr.Path)
+
+			// Everything but the failed directories and their subdirectories
+			// is indexed
+			tree := readTree(t, targetDir)
+			assert.Len(t, tree, 103-(1+3)-(1+4+4*3)+1)
+			assert.Contains(t, tree, "index.html")
+			assert.Contains(t, tree, filepath.Join("dir1", "sub1", "index.html"))
+			assert.NotContains(t, tree, filepath.Join("dir1", "sub2", "index.html"))
+			assert.Contains(t, tree, filepath.Join("stale", "index.html"))
+		})
+	}
+}
+
+func TestGenerate_KeepGoingWriteFailure(t *testing.T) {
+	mockSource := new(MockSource)
+	mockTarget := new(MockSource)
+	indexer := Indexer{
+		Cfg: Config{
+			Recursive:  true,
+			KeepGoing:  true,
+			IndexFile:  "index.html",
+			BasePath:   "/",
+			SortBy:     "name",
+			Order:      "asc",
+			DateFormat: "2006-01-02",
+		},
+		Source: mockSource,
+		Target: mockTarget,
+	}
+
+	mockSource.On("Read", "/").Return([]*Item{{Name: "a", IsDir: true}, {Name: "b", IsDir: true}}, false, nil)
+	mockSource.On("Read", "/a").Return([]*Item{{Name: "file.txt"}}, false, nil)
+	mockSource.On("Read", "/b").Return([]*Item{{Name: "file.txt"}}, false, nil)
+	mockTarget.On("EnsureDirExists", "/").Return(nil)
+	mockTarget.On("EnsureDirExists", "/a").Return(nil)
+	mockTarget.On("EnsureDirExists", "/b").Return(nil)
+
+	forDir := func(relativePath string) any {
+		return mock.MatchedBy(func(data Data) bool { return data.RelativePath == relativePath })
+	}
+	writeErr := errors.New("permission denied")
+	mockTarget.On("Write", forDir("/a"), mock.Anything).Return(writeErr)
+	mockTarget.On("Write", forDir("/b"), mock.Anything).Return(nil)
+	mockTarget.On("Write", forDir("/"), mock.Anything).Return(nil)
+
+	_, err := indexer.Generate(t.Context(), nil, "/")
+	var failed *GenerateError
+	require.ErrorAs(t, err, &failed)
+	require.Len(t, failed.Failures, 1)
+	assert.Equal(t, "/a", failed.Failures[0].Path)
+	assert.Equal(t, "write", failed.Failures[0].Op)
+	require.ErrorIs(t, err, writeErr)
+
+	mockTarget.AssertExpectations(t)
+}
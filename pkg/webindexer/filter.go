@@ -0,0 +1,220 @@
+package webindexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// FileReader is implemented by sources that can read the content of their
+// files. Ignore files and per-directory configuration files are read through
+// it, so sources that don't implement it can't use them.
+type FileReader interface {
+	// ReadFile returns the content of the named file in dir, a path as passed
+	// to Read, or an error wrapping fs.ErrNotExist if there is none.
+	ReadFile(ctx context.Context, dir, name string) ([]byte, error)
+}
+
+// includeSearcher is implemented by sources that can rule out subdirectories
+// without any file matching the include patterns below them faster than by
+// reading each of their directories.
+type includeSearcher interface {
+	// hasIncludedFiles reports whether the subdirectory name of dir, a path as
+	// passed to Read, has a file matching the include patterns below it. The
+	// directories it reports true for are still read, as the file may be
+	// ignored or in a directory with a noindex file.
+	hasIncludedFiles(ctx context.Context, dir, name string) (bool, error)
+}
+
+// itemFilter holds the ignore files and include search results cached between
+// the directories of a run. The zero value is ready to use.
+type itemFilter struct {
+	ignores  ignoreCache
+	includes dirFlags
+}
+
+// filterItems removes the items of a directory that the hidden file policy,
+// ignore files or include patterns leave out, along with the ignore files and
+// the configuration file themselves. It applies to every source, after the
+// source has applied the skip patterns.
+func (i Indexer) filterItems(ctx context.Context, path string, items []*Item) ([]*Item, error) {
+	items, err := i.visibleItems(ctx, path, items)
+	if err != nil || len(i.Cfg.Includes) == 0 {
+		return items, err
+	}
+
+	kept := items[:0]
+	for _, item := range items {
+		switch {
+		case item.NoIndex:
+			// Directories with a noindex file are only kept to be reported
+		case item.IsDir:
+			matched, err := i.hasIncludedFiles(ctx, path, item.Name)
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				continue
+			}
+		case !matchPatterns(item.Name, itemPath(i.Cfg, path, item.Name), i.Cfg.Includes):
+			continue
+		}
+
+		kept = append(kept, item)
+	}
+
+	return kept, nil
+}
+
+// visibleItems removes the items of a directory that the hidden file policy or
+// ignore files leave out, along with the ignore files and the configuration
+// file themselves.
+func (i Indexer) visibleItems(ctx context.Context, path string, items []*Item) ([]*Item, error) {
+	present := map[string]bool{}
+	for _, item := range items {
+		if !item.IsDir {
+			present[item.Name] = true
+		}
+	}
+
+	var ignores gitignore.Matcher
+	if len(i.Cfg.IgnoreFiles) > 0 {
+		var err error
+		ignores, err = i.ignoreMatcher(ctx, path, present)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	kept := items[:0]
+	for _, item := range items {
+		relativePath := itemPath(i.Cfg, path, item.Name)
+		if hiddenItem(i.Cfg, item.Name, relativePath) ||
+			(!item.IsDir && sourceFile(i.Cfg, item.Name)) ||
+			(ignores != nil && ignored(ignores, relativePath, item.IsDir)) {
+			continue
+		}
+
+		kept = append(kept, item)
+	}
+
+	return kept, nil
+}
+
+// ignoreMatcher returns a matcher for the ignore files that apply to the items
+// of path. present holds the names of the files of path, so that ignore files
+// that don't exist aren't read.
+func (i Indexer) ignoreMatcher(ctx context.Context, path string, present map[string]bool) (gitignore.Matcher, error) {
+	dir := itemPath(i.Cfg, path, "")
+	load := func(ctx context.Context, ignoreDir, name string) ([]byte, error) {
+		if ignoreDir == dir {
+			if !present[name] {
+				return nil, fs.ErrNotExist
+			}
+			return i.readSourceFile(ctx, path, name)
+		}
+
+		// The parents of path are normally read first, so this is only
+		// reached when generating from a subdirectory of the source. Their
+		// listings aren't known, so sources that can't read files are
+		// assumed not to have ignore files there.
+		if _, ok := i.Source.(FileReader); !ok {
+			return nil, fs.ErrNotExist
+		}
+		return i.readSourceFile(ctx, filepath.Join(i.Cfg.BasePath, filepath.FromSlash(ignoreDir)), name)
+	}
+
+	return i.filter().ignores.matcher(ctx, i.Cfg.IgnoreFiles, dir, load)
+}
+
+// hasIncludedFiles reports whether the subdirectory name of dir has a file
+// matching the include patterns anywhere below it. The directories below it
+// are read and filtered the same way as when they're indexed, so files that
+// are skipped, hidden or ignored, or in a directory with a noindex file, don't
+// count.
+func (i Indexer) hasIncludedFiles(ctx context.Context, dir, name string) (bool, error) {
+	if searcher, ok := i.Source.(includeSearcher); ok {
+		matched, err := searcher.hasIncludedFiles(ctx, dir, name)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	subDir := filepath.Join(dir, name)
+	relativeDir := itemPath(i.Cfg, subDir, "")
+	if matched, ok := i.filter().includes.get(relativeDir); ok {
+		return matched, nil
+	}
+
+	items, hasNoIndex, err := i.Source.Read(ctx, subDir)
+	if err != nil || hasNoIndex {
+		return false, err
+	}
+
+	// The directory's configuration file adds skips for it and below
+	i.Cfg, items, err = i.dirConfig(ctx, subDir, items)
+	if err != nil {
+		return false, err
+	}
+
+	items, err = i.visibleItems(ctx, subDir, items)
+	if err != nil {
+		return false, err
+	}
+
+	matched := false
+	for _, item := range items {
+		if matched || ctx.Err() != nil {
+			break
+		}
+
+		switch {
+		case item.NoIndex:
+			continue
+		case item.IsDir:
+			matched, err = i.hasIncludedFiles(ctx, subDir, item.Name)
+			if err != nil {
+				return false, err
+			}
+		default:
+			matched = matchPatterns(item.Name, itemPath(i.Cfg, subDir, item.Name), i.Cfg.Includes)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	i.filter().includes.set(relativeDir, matched)
+	return matched, nil
+}
+
+// sourceFile reports whether name is an ignore file or the per-directory
+// configuration file, the files the indexer reads from sources.
+func sourceFile(cfg Config, name string) bool {
+	return contains(cfg.IgnoreFiles, name) || (cfg.DirConfigFile != "" && name == cfg.DirConfigFile)
+}
+
+// readSourceFile reads a file from a directory of the source, or returns an
+// error if the source can't read files.
+func (i Indexer) readSourceFile(ctx context.Context, dir, name string) ([]byte, error) {
+	reader, ok := i.Source.(FileReader)
+	if !ok {
+		return nil, fmt.Errorf("reading files from %s is %w", i.Cfg.Source, errors.ErrUnsupported)
+	}
+
+	return reader.ReadFile(ctx, dir, name)
+}
+
+// filter returns the cache of the item filters, or an empty one for indexers
+// that weren't created with New.
+func (i Indexer) filter() *itemFilter {
+	if i.filters == nil {
+		return &itemFilter{}
+	}
+	return i.filters
+}
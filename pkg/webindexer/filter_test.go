@@ -0,0 +1,95 @@
+package webindexer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_FiltersAnySource(t *testing.T) {
+	fsys := fstest.MapFS{
+		".DS_Store":                    {Data: []byte("x")},
+		".webindexerignore":            {Data: []byte("*.log\n")},
+		"debug.log":                    {Data: []byte("x")},
+		"readme.txt":                   {Data: []byte("x")},
+		"docs/guide.txt":               {Data: []byte("x")},
+		"docs/" + DefaultDirConfigFile: {Data: []byte("title: Docs\n")},
+		"pkg/linux/app_1.0.deb":        {Data: []byte("x")},
+		"empty/notes.txt":              {Data: []byte("x")},
+	}
+
+	generate := func(cfg Config) map[string]string {
+		t.Helper()
+
+		cfg.Target = t.TempDir()
+		cfg.Recursive = true
+		cfg.IndexFile = "index.html"
+		cfg.SortBy = "name"
+		cfg.Order = "asc"
+
+		indexer, err := NewWithSource(cfg, NewFSSource(fsys, cfg))
+		require.NoError(t, err)
+		_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+		require.NoError(t, err)
+
+		indexes := map[string]string{}
+		for _, dir := range []string{"", "docs", "pkg", "empty"} {
+			content, err := os.ReadFile(filepath.Join(cfg.Target, dir, "index.html"))
+			if err == nil {
+				indexes[dir] = string(content)
+			}
+		}
+		return indexes
+	}
+
+	indexes := generate(Config{
+		Hidden:        "hide",
+		IgnoreFiles:   []string{".webindexerignore"},
+		DirConfigFile: DefaultDirConfigFile,
+	})
+	assert.Contains(t, indexes[""], "readme.txt")
+	assert.NotContains(t, indexes[""], ".DS_Store")
+	assert.NotContains(t, indexes[""], "debug.log")
+	assert.NotContains(t, indexes[""], ".webindexerignore")
+	assert.Contains(t, indexes["docs"], "<title>Docs</title>")
+	assert.NotContains(t, indexes["docs"], DefaultDirConfigFile)
+
+	// The source doesn't search for included files itself, so its directories
+	// are read to find them
+	indexes = generate(Config{Includes: []string{"*.deb"}})
+	assert.Contains(t, indexes[""], "pkg/")
+	assert.NotContains(t, indexes[""], "empty/")
+	assert.NotContains(t, indexes[""], "readme.txt")
+	assert.NotContains(t, indexes, "empty")
+}
+
+func TestFilterItems_UnreadableSource(t *testing.T) {
+	fsys := fstest.MapFS{
+		"readme.txt":             {Data: []byte("x")},
+		"docs/.webindexerignore": {Data: []byte("*.log\n")},
+	}
+	cfg := Config{
+		BasePath:    "/",
+		IndexFile:   "index.html",
+		IgnoreFiles: []string{".webindexerignore"},
+	}
+	// Hide the FileReader implementation of the source
+	indexer := Indexer{Cfg: cfg, Source: struct{ FileSource }{NewFSSource(fsys, cfg)}}
+
+	// Directories without an ignore file don't need to read one
+	items, _, err := indexer.Source.Read(t.Context(), "/")
+	require.NoError(t, err)
+	items, err = indexer.filterItems(t.Context(), "/", items)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs", "readme.txt"}, itemNames(items))
+
+	items, _, err = indexer.Source.Read(t.Context(), "/docs")
+	require.NoError(t, err)
+	_, err = indexer.filterItems(t.Context(), "/docs", items)
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+}
@@ -0,0 +1,127 @@
+package webindexer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// FSBackend is a read-only FileSource backed by an fs.FS, such as an
+// embed.FS, fstest.MapFS or os.DirFS. Paths are slash separated and relative
+// to the root of the filesystem, which is listed as "/".
+type FSBackend struct {
+	fsys fs.FS
+	cfg  Config
+}
+
+var (
+	_ FileSource = &FSBackend{}
+	_ FileReader = &FSBackend{}
+)
+
+// NewFSSource returns a FileSource that lists the contents of fsys. Use it
+// with NewWithSource to index a filesystem from Go code.
+func NewFSSource(fsys fs.FS, cfg Config) *FSBackend {
+	return &FSBackend{fsys: fsys, cfg: cfg}
+}
+
+// fsName converts a listing path to a name that fs.FS accepts.
+func fsName(p string) string {
+	name := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+func (f *FSBackend) Read(ctx context.Context, dir string) ([]*Item, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	name := fsName(dir)
+	log.Debugf("Listing files in %s", name)
+
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to read source path %s: %w", name, err)
+	}
+
+	// First check for noindex files before processing anything else
+	noIndex, skipIndex := skipListing(f.cfg, name, dirEntryFileNames(entries))
+	if noIndex {
+		return nil, true, nil
+	}
+	if skipIndex {
+		return []*Item{}, false, nil
+	}
+
+	var items []*Item
+	for _, entry := range entries {
+		if shouldSkip(entry.Name(), itemPath(f.cfg, dir, entry.Name()), f.cfg.IndexFile, f.cfg.Skips) {
+			continue
+		}
+
+		fullName := path.Join(name, entry.Name())
+		info, err := fs.Stat(f.fsys, fullName)
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to stat file %s: %w", fullName, err)
+		}
+
+		// Check if this directory contains a noindex file
+		excluded := false
+		if info.IsDir() {
+			excluded, err = f.hasNoIndexFile(fullName)
+			if err != nil {
+				return nil, false, err
+			}
+		}
+
+		// Filesystems such as embed.FS don't record modification times, so
+		// only report metadata that is actually known.
+		items = append(items, &Item{
+			Name:         entry.Name(),
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+			IsDir:        info.IsDir(),
+			HasMetadata:  !info.ModTime().IsZero(),
+			NoIndex:      excluded,
+		})
+	}
+
+	return items, false, nil
+}
+
+// hasNoIndexFile reports whether the given directory directly contains a
+// noindex file.
+func (f *FSBackend) hasNoIndexFile(dir string) (bool, error) {
+	if len(f.cfg.NoIndexFiles) == 0 {
+		return false, nil
+	}
+
+	entries, err := fs.ReadDir(f.fsys, dir)
+	if err != nil {
+		return false, fmt.Errorf("unable to read directory %s: %w", dir, err)
+	}
+
+	return excludedDir(f.cfg, dir, dirEntryFileNames(entries)), nil
+}
+
+// ReadFile reads a file from a directory of the filesystem.
+func (f *FSBackend) ReadFile(_ context.Context, dir, name string) ([]byte, error) {
+	return fs.ReadFile(f.fsys, path.Join(fsName(dir), name))
+}
+
+// EnsureDirExists is not supported as fs.FS is read-only.
+func (f *FSBackend) EnsureDirExists(_ context.Context, relativePath string) error {
+	return fmt.Errorf("unable to create %s: %w", relativePath, ErrReadOnlySource)
+}
+
+// Write is not supported as fs.FS is read-only.
+func (f *FSBackend) Write(_ context.Context, data Data, _ string) error {
+	return fmt.Errorf("unable to write index for %s: %w", data.RelativePath, ErrReadOnlySource)
+}
@@ -0,0 +1,118 @@
+package webindexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fsModTime = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+func newTestMapFS() fstest.MapFS {
+	return fstest.MapFS{
+		"README.md":          {Data: []byte("hello"), ModTime: fsModTime},
+		"docs/guide.md":      {Data: []byte("guide text"), ModTime: fsModTime},
+		"private/.noindex":   {},
+		"private/secret.txt": {Data: []byte("secret")},
+		"skip.me":            {Data: []byte("x"), ModTime: fsModTime},
+		"index.html":         {Data: []byte("<html></html>"), ModTime: fsModTime},
+	}
+}
+
+func TestFSBackendRead(t *testing.T) {
+	backend := NewFSSource(newTestMapFS(), Config{
+		IndexFile:    "index.html",
+		NoIndexFiles: []string{".noindex"},
+		Skips:        []string{"skip.me"},
+	})
+
+	items, hasNoIndex, err := backend.Read(t.Context(), "/")
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+	require.Len(t, items, 3)
+
+	assert.Equal(t, "README.md", items[0].Name)
+	assert.Equal(t, int64(5), items[0].Size)
+	assert.True(t, items[0].LastModified.Equal(fsModTime))
+	assert.True(t, items[0].HasMetadata)
+
+	// Directories implied by fstest.MapFS have no modification time
+	assert.Equal(t, "docs", items[1].Name)
+	assert.True(t, items[1].IsDir)
+	assert.False(t, items[1].HasMetadata)
+	assert.False(t, items[1].NoIndex)
+
+	assert.Equal(t, "private", items[2].Name)
+	assert.True(t, items[2].NoIndex)
+
+	items, _, err = backend.Read(t.Context(), "/docs")
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "guide.md", items[0].Name)
+
+	_, hasNoIndex, err = backend.Read(t.Context(), "/private")
+	require.NoError(t, err)
+	assert.True(t, hasNoIndex)
+
+	_, _, err = backend.Read(t.Context(), "/missing")
+	assert.Error(t, err)
+}
+
+func TestFSBackendIsReadOnly(t *testing.T) {
+	backend := NewFSSource(newTestMapFS(), Config{})
+
+	require.ErrorIs(t, backend.Write(t.Context(), Data{RelativePath: "/"}, ""), ErrReadOnlySource)
+	require.ErrorIs(t, backend.EnsureDirExists(t.Context(), "/"), ErrReadOnlySource)
+}
+
+func TestFSName(t *testing.T) {
+	assert.Equal(t, ".", fsName("/"))
+	assert.Equal(t, ".", fsName(""))
+	assert.Equal(t, "docs", fsName("/docs"))
+	assert.Equal(t, "docs/guide", fsName("/docs/guide/"))
+}
+
+func TestNewWithSource(t *testing.T) {
+	targetDir := t.TempDir()
+	cfg := Config{
+		Target:       targetDir,
+		Recursive:    true,
+		IndexFile:    "index.html",
+		NoIndexFiles: []string{".noindex"},
+		SortBy:       "name",
+		Order:        "asc",
+		DateFormat:   "2006-01-02",
+	}
+
+	indexer, err := NewWithSource(cfg, NewFSSource(newTestMapFS(), cfg))
+	require.NoError(t, err)
+	assert.Equal(t, "/", indexer.Cfg.BasePath)
+
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+
+	root, err := os.ReadFile(filepath.Join(targetDir, "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(root), "README.md")
+	assert.Contains(t, string(root), "docs/")
+	// The directory's date comes from its contents
+	assert.Contains(t, string(root), "2024-05-06")
+
+	docs, err := os.ReadFile(filepath.Join(targetDir, "docs", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(docs), "guide.md")
+
+	assert.NoDirExists(t, filepath.Join(targetDir, "private"))
+
+	_, err = NewWithSource(Config{
+		Target: "https://example.com/pub",
+		SortBy: "name",
+		Order:  "asc",
+	}, NewFSSource(newTestMapFS(), cfg))
+	require.ErrorIs(t, err, ErrReadOnlySource)
+}
@@ -0,0 +1,240 @@
+package webindexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/charmbracelet/log"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+type GCSBackend struct {
+	svc    GCSAPI
+	bucket string
+	cfg    Config
+}
+
+// GCSAPI is the subset of Google Cloud Storage operations used by the
+// GCSBackend.
+type GCSAPI interface {
+	// ListObjects returns every object and synthetic directory prefix directly
+	// under the given prefix.
+	ListObjects(ctx context.Context, bucket, prefix string) ([]*storage.ObjectAttrs, error)
+	// GetObject returns the content of the object, or an error wrapping
+	// fs.ErrNotExist if there is none.
+	GetObject(ctx context.Context, bucket, key string) ([]byte, error)
+	PutObject(ctx context.Context, bucket, key, contentType string, body io.Reader) error
+}
+
+var (
+	_ FileSource = &GCSBackend{}
+	_ FileReader = &GCSBackend{}
+)
+
+// gcsClient implements GCSAPI using the Google Cloud Storage client library.
+type gcsClient struct {
+	client *storage.Client
+}
+
+var _ GCSAPI = &gcsClient{}
+
+// newGCSClient creates a GCS client. If an endpoint is given, requests are
+// sent there without authentication, which is useful for local fake servers.
+func newGCSClient(endpoint string) (*gcsClient, error) {
+	var opts []option.ClientOption
+	if endpoint != "" {
+		opts = append(opts, option.WithEndpoint(endpoint), option.WithoutAuthentication())
+	}
+
+	client, err := storage.NewClient(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcsClient{client: client}, nil
+}
+
+func (c *gcsClient) ListObjects(ctx context.Context, bucket, prefix string) ([]*storage.ObjectAttrs, error) {
+	var objects []*storage.ObjectAttrs
+
+	it := c.client.Bucket(bucket).Objects(ctx, &storage.Query{
+		Prefix:    prefix,
+		Delimiter: "/",
+	})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, attrs)
+	}
+
+	return objects, nil
+}
+
+func (c *gcsClient) GetObject(ctx context.Context, bucket, key string) ([]byte, error) {
+	r, err := c.client.Bucket(bucket).Object(key).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, fmt.Errorf("gs://%s/%s: %w", bucket, key, fs.ErrNotExist)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// PutObject uploads the object. If the context is canceled before the upload
+// completes, the object is not created or replaced.
+func (c *gcsClient) PutObject(ctx context.Context, bucket, key, contentType string, body io.Reader) error {
+	w := c.client.Bucket(bucket).Object(key).NewWriter(ctx)
+	w.ContentType = contentType
+	// Index files are small, so upload them in a single request rather than
+	// starting a resumable upload session.
+	w.ChunkSize = 0
+
+	if _, err := io.Copy(w, body); err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+func (g *GCSBackend) Read(ctx context.Context, prefix string) ([]*Item, bool, error) {
+	// Ensure the prefix has a trailing slash for object names
+	if !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
+	// Remove leading slash for object names
+	prefix = strings.TrimPrefix(prefix, "/")
+
+	log.Debugf("Listing objects in gs://%s/%s", g.bucket, prefix)
+
+	objects, err := g.svc.ListObjects(ctx, g.bucket, prefix)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to list GCS objects: %w", err)
+	}
+
+	// First check for noindex files or skipindex files before processing anything else
+	noIndex, skipIndex := skipListing(g.cfg, "gs://"+g.bucket+"/"+prefix, gcsFileNames(objects))
+	if noIndex {
+		return nil, true, nil
+	}
+	if skipIndex {
+		return []*Item{}, false, nil
+	}
+
+	var items []*Item
+	var dirs []*Item
+	for _, obj := range objects {
+		// Synthetic directories only have their prefix set
+		if obj.Prefix != "" {
+			name := strings.TrimPrefix(obj.Prefix, prefix)
+			if matchPatterns(name, itemPath(g.cfg, prefix, name), g.cfg.Skips) {
+				continue
+			}
+
+			hasNoIndex, err := g.hasNoIndexFile(ctx, obj.Prefix)
+			if err != nil {
+				return nil, false, err
+			}
+
+			dirs = append(dirs, &Item{
+				Name:    name,
+				IsDir:   true,
+				NoIndex: hasNoIndex,
+			})
+			continue
+		}
+
+		// Some tools create zero-byte placeholder objects for directories
+		if obj.Name == prefix {
+			continue
+		}
+
+		name := strings.TrimPrefix(obj.Name, prefix)
+		if shouldSkip(name, itemPath(g.cfg, prefix, name), g.cfg.IndexFile, g.cfg.Skips) {
+			continue
+		}
+
+		items = append(items, &Item{
+			Name:         name,
+			Size:         obj.Size,
+			LastModified: obj.Updated.Local(),
+			IsDir:        false,
+			HasMetadata:  true,
+		})
+	}
+
+	return append(items, dirs...), false, nil
+}
+
+// hasNoIndexFile reports whether the given prefix directly contains a noindex
+// file.
+func (g *GCSBackend) hasNoIndexFile(ctx context.Context, prefix string) (bool, error) {
+	if len(g.cfg.NoIndexFiles) == 0 {
+		return false, nil
+	}
+
+	objects, err := g.svc.ListObjects(ctx, g.bucket, prefix)
+	if err != nil {
+		return false, fmt.Errorf("unable to list GCS objects in prefix %s: %w", prefix, err)
+	}
+
+	return excludedDir(g.cfg, "gs://"+g.bucket+"/"+prefix, gcsFileNames(objects)), nil
+}
+
+// gcsFileNames returns the base names of the objects in a listing, leaving
+// out the synthetic directories.
+func gcsFileNames(objects []*storage.ObjectAttrs) []string {
+	var names []string
+	for _, obj := range objects {
+		if obj.Prefix == "" {
+			names = append(names, filepath.Base(obj.Name))
+		}
+	}
+	return names
+}
+
+// ReadFile reads an object from a directory of the source.
+func (g *GCSBackend) ReadFile(ctx context.Context, prefix, name string) ([]byte, error) {
+	return g.svc.GetObject(ctx, g.bucket, objectPrefix(prefix)+name)
+}
+
+// EnsureDirExists is a no-op for GCS as directories are implicit.
+func (g *GCSBackend) EnsureDirExists(_ context.Context, relativePath string) error {
+	log.Debugf("EnsureDirExists called for GCS (no-op): gs://%s/%s", g.bucket, relativePath)
+	return nil
+}
+
+func (g *GCSBackend) Write(ctx context.Context, data Data, content string) error {
+	bucket, target := uriToBucketAndPrefix(g.cfg.Target)
+	target = strings.TrimPrefix(target, g.cfg.BasePath)
+	target = filepath.Join(target, data.RelativePath, g.cfg.IndexFile)
+
+	// Object names are relative to the bucket root
+	target = strings.TrimPrefix(target, "/")
+
+	strReader := strings.NewReader(content)
+	size := humanizeBytes(int64(strReader.Len()))
+	log.Infof("Uploading %s to gs://%s/%s", size, bucket, target)
+
+	return g.svc.PutObject(ctx, bucket, target, "text/html", strReader)
+}
+
+func isGCSURI(uri string) bool {
+	return strings.HasPrefix(uri, "gs://")
+}
@@ -0,0 +1,334 @@
+package webindexer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockGCSClient struct {
+	mock.Mock
+}
+
+func (m *MockGCSClient) ListObjects(_ context.Context, bucket, prefix string) ([]*storage.ObjectAttrs, error) {
+	args := m.Called(bucket, prefix)
+	return args.Get(0).([]*storage.ObjectAttrs), args.Error(1)
+}
+
+func (m *MockGCSClient) GetObject(_ context.Context, bucket, key string) ([]byte, error) {
+	args := m.Called(bucket, key)
+	content, _ := args.Get(0).([]byte)
+	return content, args.Error(1)
+}
+
+func (m *MockGCSClient) PutObject(_ context.Context, bucket, key, contentType string, body io.Reader) error {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	args := m.Called(bucket, key, contentType, string(content))
+	return args.Error(0)
+}
+
+func TestGCSBackendRead(t *testing.T) {
+	mockSvc := new(MockGCSClient)
+	backend := GCSBackend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			IndexFile:    "index.html",
+			NoIndexFiles: []string{".noindex"},
+			Skips:        []string{"prefix/skipped.txt"},
+		},
+	}
+
+	mockSvc.On("ListObjects", "test-bucket", "prefix/").Return([]*storage.ObjectAttrs{
+		{Name: "prefix/", Size: 0, Updated: time.Now()},
+		{Name: "prefix/file1.txt", Size: 1024, Updated: time.Now()},
+		{Name: "prefix/index.html", Size: 512, Updated: time.Now()},
+		{Name: "prefix/skipped.txt", Size: 1, Updated: time.Now()},
+		{Prefix: "prefix/dir1/"},
+		{Prefix: "prefix/private/"},
+	}, nil)
+	mockSvc.On("ListObjects", "test-bucket", "prefix/dir1/").Return([]*storage.ObjectAttrs{
+		{Name: "prefix/dir1/file2.txt", Size: 2048, Updated: time.Now()},
+	}, nil)
+	mockSvc.On("ListObjects", "test-bucket", "prefix/private/").Return([]*storage.ObjectAttrs{
+		{Name: "prefix/private/.noindex", Size: 0, Updated: time.Now()},
+	}, nil)
+
+	items, hasNoIndex, err := backend.Read(t.Context(), "/prefix")
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+	require.Len(t, items, 3)
+
+	assert.Equal(t, "file1.txt", items[0].Name)
+	assert.Equal(t, int64(1024), items[0].Size)
+	assert.False(t, items[0].IsDir)
+	assert.True(t, items[0].HasMetadata)
+
+	assert.Equal(t, "dir1/", items[1].Name)
+	assert.True(t, items[1].IsDir)
+	assert.False(t, items[1].NoIndex)
+
+	assert.Equal(t, "private/", items[2].Name)
+	assert.True(t, items[2].NoIndex)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGCSBackendReadWithNoIndex(t *testing.T) {
+	mockSvc := new(MockGCSClient)
+	backend := GCSBackend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			NoIndexFiles: []string{".noindex"},
+		},
+	}
+
+	mockSvc.On("ListObjects", "test-bucket", "prefix/").Return([]*storage.ObjectAttrs{
+		{Name: "prefix/.noindex", Size: 0, Updated: time.Now()},
+		{Name: "prefix/file1.txt", Size: 1024, Updated: time.Now()},
+	}, nil)
+
+	items, hasNoIndex, err := backend.Read(t.Context(), "prefix/")
+	require.NoError(t, err)
+	assert.True(t, hasNoIndex)
+	assert.Empty(t, items)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGCSBackendReadWithSkipIndex(t *testing.T) {
+	mockSvc := new(MockGCSClient)
+	backend := GCSBackend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			SkipIndexFiles: []string{".skipindex"},
+		},
+	}
+
+	mockSvc.On("ListObjects", "test-bucket", "prefix/").Return([]*storage.ObjectAttrs{
+		{Name: "prefix/.skipindex", Size: 0, Updated: time.Now()},
+		{Name: "prefix/file1.txt", Size: 1024, Updated: time.Now()},
+	}, nil)
+
+	items, hasNoIndex, err := backend.Read(t.Context(), "prefix/")
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+	assert.Empty(t, items)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGCSBackendWrite(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		basePath     string
+		relativePath string
+		expectedKey  string
+	}{
+		{"bucket root", "gs://test-bucket/", "/", "/", "index.html"},
+		{"subdirectory", "gs://test-bucket/", "/", "/subdir", "subdir/index.html"},
+		{"target prefix", "gs://test-bucket/site", "/basepath", "/subdir", "site/subdir/index.html"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockGCSClient)
+			backend := GCSBackend{
+				svc: mockSvc,
+				cfg: Config{
+					Target:    tc.target,
+					BasePath:  tc.basePath,
+					IndexFile: "index.html",
+				},
+			}
+
+			content := "<html>Test Content</html>"
+			mockSvc.On("PutObject", "test-bucket", tc.expectedKey, "text/html", content).Return(nil)
+
+			err := backend.Write(t.Context(), Data{RelativePath: tc.relativePath}, content)
+			require.NoError(t, err)
+
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIsGCSURI(t *testing.T) {
+	assert.True(t, isGCSURI("gs://test-bucket/"))
+	assert.True(t, isGCSURI("gs://test-bucket/one/two"))
+	assert.False(t, isGCSURI("s3://test-bucket/"))
+	assert.False(t, isGCSURI("/mnt/foo"))
+}
+
+func TestGCSURIToBucketAndPrefix(t *testing.T) {
+	bucket, prefix := uriToBucketAndPrefix("gs://test-bucket/one/two")
+	assert.Equal(t, "test-bucket", bucket)
+	assert.Equal(t, "one/two", prefix)
+}
+
+// fakeGCSServer is a minimal stand-in for the GCS JSON API that supports
+// listing with a delimiter and multipart uploads.
+type fakeGCSServer struct {
+	mu      sync.Mutex
+	objects map[string]string
+	types   map[string]string
+}
+
+func (f *fakeGCSServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/test-bucket/o":
+		prefix := r.URL.Query().Get("prefix")
+		delimiter := r.URL.Query().Get("delimiter")
+
+		var items []map[string]any
+		seen := map[string]bool{}
+		var prefixes []string
+		for name, content := range f.objects {
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			rest := strings.TrimPrefix(name, prefix)
+			if idx := strings.Index(rest, delimiter); delimiter != "" && idx >= 0 {
+				p := prefix + rest[:idx+1]
+				if !seen[p] {
+					seen[p] = true
+					prefixes = append(prefixes, p)
+				}
+				continue
+			}
+			items = append(items, map[string]any{
+				"name":    name,
+				"bucket":  "test-bucket",
+				"size":    strconv.Itoa(len(content)),
+				"updated": "2024-01-02T03:04:05Z",
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"kind":     "storage#objects",
+			"items":    items,
+			"prefixes": prefixes,
+		})
+	case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/test-bucket/o":
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mr := multipart.NewReader(r.Body, params["boundary"])
+
+		var meta struct {
+			Name        string `json:"name"`
+			ContentType string `json:"contentType"`
+		}
+		part, err := mr.NextPart()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(part).Decode(&meta); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		part, err = mr.NextPart()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		f.objects[meta.Name] = string(body)
+		f.types[meta.Name] = meta.ContentType
+
+		// The client verifies the CRC32C checksum of uploaded objects
+		checksum := make([]byte, 4)
+		binary.BigEndian.PutUint32(checksum, crc32.Checksum(body, crc32.MakeTable(crc32.Castagnoli)))
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"name":   meta.Name,
+			"bucket": "test-bucket",
+			"size":   strconv.Itoa(len(body)),
+			"crc32c": base64.StdEncoding.EncodeToString(checksum),
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestGCSClientAgainstFakeServer(t *testing.T) {
+	fake := &fakeGCSServer{
+		objects: map[string]string{
+			"docs/readme.txt":        "hello",
+			"docs/guide/intro.txt":   "intro",
+			"docs/private/.noindex":  "",
+			"docs/private/secret.md": "secret",
+		},
+		types: map[string]string{},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := newGCSClient(server.URL + "/storage/v1/")
+	require.NoError(t, err)
+
+	backend := GCSBackend{
+		svc:    client,
+		bucket: "test-bucket",
+		cfg: Config{
+			Target:       "gs://test-bucket/site",
+			BasePath:     "docs",
+			IndexFile:    "index.html",
+			NoIndexFiles: []string{".noindex"},
+		},
+	}
+
+	items, hasNoIndex, err := backend.Read(t.Context(), "docs")
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		if !item.NoIndex {
+			names = append(names, item.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{"readme.txt", "guide/"}, names)
+
+	err = backend.Write(t.Context(), Data{RelativePath: "/guide"}, "<html>guide</html>")
+	require.NoError(t, err)
+
+	assert.Equal(t, "<html>guide</html>", fake.objects["site/guide/index.html"])
+	assert.Equal(t, "text/html", fake.types["site/guide/index.html"])
+}
@@ -0,0 +1,238 @@
+package webindexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// GitBackend is a read-only FileSource that exposes the tree of a commit or
+// tag in a git repository without checking it out.
+//
+// Sources are given as git+file:///path/to/repo or git+https://host/repo.git,
+// with optional "ref" (default HEAD) and "path" (a subdirectory of the tree)
+// query parameters, e.g. git+file:///srv/repo?ref=v1.2.0&path=docs.
+type GitBackend struct {
+	uri  string
+	cfg  Config
+	tree *entryTree
+}
+
+var (
+	_ FileSource = &GitBackend{}
+	_ FileReader = &GitBackend{}
+)
+
+func isGitURI(uri string) bool {
+	return strings.HasPrefix(uri, "git+")
+}
+
+// newGitBackend resolves the ref in the repository and loads its tree. Each
+// file's modification time is the committer time of the last commit that
+// changed it. The ignore files and per-directory configuration files are read
+// as well.
+func newGitBackend(uri string, cfg Config) (*GitBackend, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid git URI %s: %w", uri, err)
+	}
+
+	ref := u.Query().Get("ref")
+	if ref == "" {
+		ref = "HEAD"
+	}
+	subPath := strings.Trim(u.Query().Get("path"), "/")
+
+	repo, err := openGitRepository(u)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve git ref %s: %w", ref, err)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read commit %s: %w", hash, err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read tree of commit %s: %w", hash, err)
+	}
+
+	if subPath != "" {
+		tree, err = tree.Tree(subPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find %s in %s: %w", subPath, ref, err)
+		}
+	}
+
+	log.Debugf("Reading git tree for %s (%s)", ref, hash)
+
+	files := map[string]int64{}
+	contents := map[string][]byte{}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		files[f.Name] = f.Size
+		if sourceFile(cfg, path.Base(f.Name)) {
+			content, err := f.Contents()
+			if err != nil {
+				return err
+			}
+			contents[f.Name] = []byte(content)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list files in %s: %w", ref, err)
+	}
+
+	lastModified, err := gitLastModified(repo, commit, subPath, files)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &GitBackend{uri: uri, cfg: cfg, tree: newEntryTree()}
+	for name, size := range files {
+		g.tree.add(name, false, size, lastModified[name])
+	}
+	for name, content := range contents {
+		g.tree.setContent(name, content)
+	}
+
+	return g, nil
+}
+
+// openGitRepository opens a local repository, or clones a remote one into
+// memory without a working tree.
+func openGitRepository(u *url.URL) (*git.Repository, error) {
+	scheme := strings.TrimPrefix(u.Scheme, "git+")
+	if scheme == "file" {
+		repo, err := git.PlainOpenWithOptions(u.Path, &git.PlainOpenOptions{DetectDotGit: true})
+		if err != nil {
+			return nil, fmt.Errorf("unable to open git repository %s: %w", u.Path, err)
+		}
+		return repo, nil
+	}
+
+	if scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("unsupported git URI scheme %s", u.Scheme)
+	}
+
+	remote := *u
+	remote.Scheme = scheme
+	remote.RawQuery = ""
+
+	log.Debugf("Cloning %s", remote.Redacted())
+	repo, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{URL: remote.String(), Tags: git.AllTags})
+	if err != nil {
+		return nil, fmt.Errorf("unable to clone git repository %s: %w", remote.Redacted(), err)
+	}
+
+	return repo, nil
+}
+
+// gitLastModified walks the history from the commit and returns the time of
+// the most recent commit that changed each file. Paths are relative to
+// subPath.
+func gitLastModified(
+	repo *git.Repository,
+	commit *object.Commit,
+	subPath string,
+	files map[string]int64,
+) (map[string]time.Time, error) {
+	result := make(map[string]time.Time, len(files))
+
+	iter, err := repo.Log(&git.LogOptions{From: commit.Hash, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read history of %s: %w", commit.Hash, err)
+	}
+	defer iter.Close()
+
+	prefix := ""
+	if subPath != "" {
+		prefix = subPath + "/"
+	}
+
+	err = iter.ForEach(func(c *object.Commit) error {
+		tree, err := c.Tree()
+		if err != nil {
+			return err
+		}
+
+		var parentTree *object.Tree
+		if c.NumParents() > 0 {
+			parent, err := c.Parent(0)
+			if err != nil {
+				return err
+			}
+			if parentTree, err = parent.Tree(); err != nil {
+				return err
+			}
+		}
+
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			// Deletions have no destination and can't be the last change to a
+			// file that still exists.
+			name, found := strings.CutPrefix(change.To.Name, prefix)
+			if change.To.Name == "" || !found {
+				continue
+			}
+			if _, ok := files[name]; !ok {
+				continue
+			}
+			if _, ok := result[name]; !ok {
+				result[name] = c.Committer.When
+			}
+		}
+
+		if len(result) == len(files) {
+			return storer.ErrStop
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, storer.ErrStop) {
+		return nil, fmt.Errorf("unable to read history of %s: %w", commit.Hash, err)
+	}
+
+	return result, nil
+}
+
+func (g *GitBackend) Read(ctx context.Context, dir string) ([]*Item, bool, error) {
+	return g.tree.read(ctx, dir, g.cfg, g.uri)
+}
+
+// ReadFile returns the content of an ignore file or per-directory
+// configuration file in the tree.
+func (g *GitBackend) ReadFile(_ context.Context, dir, name string) ([]byte, error) {
+	return g.tree.readFile(dir, name, g.uri)
+}
+
+// EnsureDirExists is not supported as git sources are read-only.
+func (g *GitBackend) EnsureDirExists(_ context.Context, relativePath string) error {
+	return fmt.Errorf("unable to create %s: %w", relativePath, ErrReadOnlySource)
+}
+
+// Write is not supported as git sources are read-only.
+func (g *GitBackend) Write(_ context.Context, data Data, _ string) error {
+	return fmt.Errorf("unable to write index for %s: %w", data.RelativePath, ErrReadOnlySource)
+}
@@ -0,0 +1,214 @@
+package webindexer
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	gitFirstCommit  = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	gitSecondCommit = time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+	gitThirdCommit  = time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+)
+
+// createTestGitRepo builds a repository with three commits. The first is
+// tagged v1.0.0 and the second changes only docs/guide.md.
+func createTestGitRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	commit := func(when time.Time, files map[string]string) {
+		for name, content := range files {
+			p := filepath.Join(dir, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+			require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+			_, err := wt.Add(name)
+			require.NoError(t, err)
+		}
+		sig := &object.Signature{Name: "Test", Email: "test@example.com", When: when}
+		_, err := wt.Commit("commit", &git.CommitOptions{Author: sig, Committer: sig})
+		require.NoError(t, err)
+	}
+
+	commit(gitFirstCommit, map[string]string{
+		"README.md":                    "hello",
+		"docs/guide.md":                "guide",
+		"private/.noindex":             "",
+		"private/secret.txt":           "secret",
+		"site/.webindexerignore":       "drafts/\n",
+		"site/" + DefaultDirConfigFile: "title: Site\n",
+		"site/drafts/todo.md":          "todo",
+		"site/page.md":                 "page",
+	})
+	head, err := repo.Head()
+	require.NoError(t, err)
+	_, err = repo.CreateTag("v1.0.0", head.Hash(), &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "Test", Email: "test@example.com", When: gitFirstCommit},
+		Message: "v1.0.0",
+	})
+	require.NoError(t, err)
+
+	commit(gitSecondCommit, map[string]string{"docs/guide.md": "updated guide"})
+	commit(gitThirdCommit, map[string]string{"CHANGELOG.md": "changes"})
+
+	return dir
+}
+
+func gitItemsByName(items []*Item) map[string]*Item {
+	byName := map[string]*Item{}
+	for _, item := range items {
+		byName[item.Name] = item
+	}
+	return byName
+}
+
+func TestGitBackendRead(t *testing.T) {
+	repoDir := createTestGitRepo(t)
+
+	backend, err := newGitBackend("git+file://"+repoDir, Config{
+		IndexFile:    "index.html",
+		NoIndexFiles: []string{".noindex"},
+	})
+	require.NoError(t, err)
+
+	items, hasNoIndex, err := backend.Read(t.Context(), "/")
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+
+	byName := gitItemsByName(items)
+	require.Len(t, byName, 5)
+	assert.True(t, byName["private"].NoIndex)
+	assert.Contains(t, byName, "CHANGELOG.md")
+	assert.True(t, byName["README.md"].LastModified.Equal(gitFirstCommit))
+	assert.Equal(t, int64(5), byName["README.md"].Size)
+	assert.True(t, byName["docs"].IsDir)
+	assert.False(t, byName["docs"].HasMetadata)
+
+	items, _, err = backend.Read(t.Context(), "/docs")
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "guide.md", items[0].Name)
+	assert.Equal(t, int64(13), items[0].Size)
+	assert.True(t, items[0].LastModified.Equal(gitSecondCommit))
+
+	_, hasNoIndex, err = backend.Read(t.Context(), "/private")
+	require.NoError(t, err)
+	assert.True(t, hasNoIndex)
+}
+
+func TestGitBackendReadRef(t *testing.T) {
+	repoDir := createTestGitRepo(t)
+
+	backend, err := newGitBackend("git+file://"+repoDir+"?ref=v1.0.0", Config{IndexFile: "index.html"})
+	require.NoError(t, err)
+
+	items, _, err := backend.Read(t.Context(), "/")
+	require.NoError(t, err)
+	assert.NotContains(t, gitItemsByName(items), "CHANGELOG.md")
+
+	items, _, err = backend.Read(t.Context(), "/docs")
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, int64(5), items[0].Size)
+	assert.True(t, items[0].LastModified.Equal(gitFirstCommit))
+
+	_, err = newGitBackend("git+file://"+repoDir+"?ref=v9.9.9", Config{})
+	assert.Error(t, err)
+}
+
+func TestGitBackendReadPath(t *testing.T) {
+	repoDir := createTestGitRepo(t)
+
+	backend, err := newGitBackend("git+file://"+repoDir+"?path=docs", Config{IndexFile: "index.html"})
+	require.NoError(t, err)
+
+	items, _, err := backend.Read(t.Context(), "/")
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "guide.md", items[0].Name)
+	assert.True(t, items[0].LastModified.Equal(gitSecondCommit))
+
+	_, err = newGitBackend("git+file://"+repoDir+"?path=missing", Config{})
+	assert.Error(t, err)
+}
+
+func TestGitBackendReadFile(t *testing.T) {
+	backend, err := newGitBackend("git+file://"+createTestGitRepo(t), Config{
+		IgnoreFiles:   []string{".webindexerignore"},
+		DirConfigFile: DefaultDirConfigFile,
+	})
+	require.NoError(t, err)
+
+	content, err := backend.ReadFile(t.Context(), "/site", ".webindexerignore")
+	require.NoError(t, err)
+	assert.Equal(t, "drafts/\n", string(content))
+
+	content, err = backend.ReadFile(t.Context(), "site", DefaultDirConfigFile)
+	require.NoError(t, err)
+	assert.Equal(t, "title: Site\n", string(content))
+
+	_, err = backend.ReadFile(t.Context(), "/", ".webindexerignore")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestGitBackendIsReadOnly(t *testing.T) {
+	backend, err := newGitBackend("git+file://"+createTestGitRepo(t), Config{})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, backend.Write(t.Context(), Data{RelativePath: "/"}, ""), ErrReadOnlySource)
+	require.ErrorIs(t, backend.EnsureDirExists(t.Context(), "/"), ErrReadOnlySource)
+}
+
+func TestGenerateFromGit(t *testing.T) {
+	repoDir := createTestGitRepo(t)
+	targetDir := t.TempDir()
+
+	indexer, err := New(Config{
+		Source:        "git+file://" + repoDir + "?ref=v1.0.0",
+		Target:        targetDir,
+		Recursive:     true,
+		IndexFile:     "index.html",
+		NoIndexFiles:  []string{".noindex"},
+		IgnoreFiles:   []string{".webindexerignore"},
+		DirConfigFile: DefaultDirConfigFile,
+		SortBy:        "name",
+		Order:         "asc",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/", indexer.Cfg.BasePath)
+
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+
+	root, err := os.ReadFile(filepath.Join(targetDir, "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(root), "README.md")
+	assert.NotContains(t, string(root), "CHANGELOG.md")
+
+	docs, err := os.ReadFile(filepath.Join(targetDir, "docs", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(docs), "guide.md")
+
+	assert.NoFileExists(t, filepath.Join(targetDir, "private", "index.html"))
+
+	site, err := os.ReadFile(filepath.Join(targetDir, "site", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(site), "<title>Site</title>")
+	assert.Contains(t, string(site), "page.md")
+	assert.NotContains(t, string(site), "drafts")
+	assert.NotContains(t, string(site), ".webindexerignore")
+	assert.NoDirExists(t, filepath.Join(targetDir, "site", "drafts"))
+}
@@ -0,0 +1,77 @@
+package webindexer
+
+import "strings"
+
+type HiddenPolicy string
+
+const (
+	// HiddenShow lists dotfiles and junk files like any other file.
+	HiddenShow HiddenPolicy = "show"
+	// HiddenHide leaves dotfiles and junk files out of listings.
+	HiddenHide HiddenPolicy = "hide"
+	// HiddenExceptAllowlist hides dotfiles and junk files unless they match
+	// one of the HiddenAllow patterns.
+	HiddenExceptAllowlist HiddenPolicy = "hide-except-allowlist"
+)
+
+// DefaultJunkFiles are the names and patterns of files created by operating
+// systems and desktop tools that are hidden along with dotfiles. JunkFiles in
+// the configuration adds to them.
+var DefaultJunkFiles = []string{
+	// macOS
+	".DS_Store",
+	"._*",
+	".AppleDouble",
+	".Spotlight-V100",
+	".Trashes",
+	".fseventsd",
+	// Windows
+	"Thumbs.db",
+	"ehthumbs.db",
+	"desktop.ini",
+	"$RECYCLE.BIN",
+	// Linux
+	".directory",
+	".Trash-*",
+	// Editors
+	"*~",
+	"*.swp",
+}
+
+func (c Config) HiddenValue() HiddenPolicy {
+	switch c.Hidden {
+	case "", "show":
+		return HiddenShow
+	case "hide":
+		return HiddenHide
+	case "hide-except-allowlist":
+		return HiddenExceptAllowlist
+	default:
+		return ""
+	}
+}
+
+// hiddenItem reports whether the hidden file policy leaves an item out of the
+// listing. Items are hidden when their name starts with a dot or they're junk
+// files. A trailing slash on the name of a directory is ignored.
+func hiddenItem(cfg Config, name, relativePath string) bool {
+	policy := cfg.HiddenValue()
+	if policy == HiddenShow {
+		return false
+	}
+
+	name = strings.TrimSuffix(name, "/")
+	if !strings.HasPrefix(name, ".") && !junkFile(cfg, name, relativePath) {
+		return false
+	}
+
+	if policy == HiddenExceptAllowlist && matchPatterns(name, relativePath, cfg.HiddenAllow) {
+		return false
+	}
+
+	return true
+}
+
+func junkFile(cfg Config, name, relativePath string) bool {
+	return matchPatterns(name, relativePath, DefaultJunkFiles) || matchPatterns(name, relativePath, cfg.JunkFiles)
+}
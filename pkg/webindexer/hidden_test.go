@@ -0,0 +1,119 @@
+package webindexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHiddenItem(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		relativePath string
+		want         bool
+	}{
+		{"show by default", Config{}, ".DS_Store", false},
+		{"show", Config{Hidden: "show"}, ".git", false},
+		{"hide dotfile", Config{Hidden: "hide"}, "a/.env", true},
+		{"hide dot directory", Config{Hidden: "hide"}, ".git/", true},
+		{"hide junk file", Config{Hidden: "hide"}, "photos/Thumbs.db", true},
+		{"hide junk pattern", Config{Hidden: "hide"}, "notes.txt~", true},
+		{"hide extra junk file", Config{Hidden: "hide", JunkFiles: []string{"*.bak"}}, "db.bak", true},
+		{"keep regular file", Config{Hidden: "hide"}, "readme.txt", false},
+		{
+			"allowlisted dotfile",
+			Config{Hidden: "hide-except-allowlist", HiddenAllow: []string{".well-known", ".htaccess"}},
+			".well-known/",
+			false,
+		},
+		{
+			"dotfile not in allowlist",
+			Config{Hidden: "hide-except-allowlist", HiddenAllow: []string{".well-known"}},
+			".env",
+			true,
+		},
+		{
+			"allowlist ignored with hide",
+			Config{Hidden: "hide", HiddenAllow: []string{".well-known"}},
+			".well-known",
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Base(tt.relativePath)
+			if tt.relativePath[len(tt.relativePath)-1] == '/' {
+				name += "/"
+			}
+			assert.Equal(t, tt.want, hiddenItem(tt.cfg, name, tt.relativePath))
+		})
+	}
+}
+
+func TestLocalBackendReadHidden(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{
+		".DS_Store",
+		".env",
+		".git/config",
+		".well-known/security.txt",
+		"Thumbs.db",
+		"readme.txt",
+	} {
+		fullPath := filepath.Join(tempDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+		require.NoError(t, os.WriteFile(fullPath, []byte("test content"), 0o644))
+	}
+
+	read := func(cfg Config) []string {
+		cfg.BasePath = tempDir
+		cfg.IndexFile = "index.html"
+		return filteredNames(t, &LocalBackend{path: tempDir, cfg: cfg}, cfg, tempDir)
+	}
+
+	assert.Equal(t, []string{".DS_Store", ".env", ".git", ".well-known", "Thumbs.db", "readme.txt"},
+		read(Config{Hidden: "show"}))
+	assert.Equal(t, []string{"readme.txt"}, read(Config{Hidden: "hide"}))
+	assert.Equal(t, []string{".well-known", "readme.txt"},
+		read(Config{Hidden: "hide-except-allowlist", HiddenAllow: []string{".well-known"}}))
+}
+
+func TestS3BackendReadHidden(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	backend := &S3Backend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			IndexFile: "index.html",
+			Hidden:    "hide",
+		},
+	}
+
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+		return *input.Prefix == "site/"
+	})).Return(&s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			{Key: aws.String("site/.DS_Store"), Size: aws.Int64(1), LastModified: aws.Time(time.Now())},
+			{Key: aws.String("site/Thumbs.db"), Size: aws.Int64(1), LastModified: aws.Time(time.Now())},
+			{Key: aws.String("site/readme.txt"), Size: aws.Int64(1), LastModified: aws.Time(time.Now())},
+		},
+		CommonPrefixes: []*s3.CommonPrefix{
+			{Prefix: aws.String("site/.git/")},
+			{Prefix: aws.String("site/docs/")},
+		},
+	}, nil)
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+		return *input.Prefix == "site/docs/" || *input.Prefix == "site/.git/"
+	})).Return(&s3.ListObjectsV2Output{}, nil)
+
+	assert.Equal(t, []string{"docs/", "readme.txt"}, filteredNames(t, backend, backend.cfg, "site/"))
+}
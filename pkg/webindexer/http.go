@@ -0,0 +1,418 @@
+package webindexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"golang.org/x/net/html"
+)
+
+// HTTPBackend is a read-only FileSource that crawls existing directory
+// listing pages, such as Apache or nginx autoindex pages or indexes
+// previously generated by web-indexer.
+type HTTPBackend struct {
+	client *http.Client
+	// baseURL holds the scheme and host of the source URI.
+	baseURL *url.URL
+	cfg     Config
+
+	// listings caches pages fetched while checking subdirectories for noindex
+	// files so that they aren't requested again when the subdirectory is read.
+	mu       sync.Mutex
+	listings map[string][]httpEntry
+}
+
+var (
+	_ FileSource = &HTTPBackend{}
+	_ FileReader = &HTTPBackend{}
+)
+
+// httpEntry is a single entry parsed from a directory listing page.
+type httpEntry struct {
+	name         string
+	isDir        bool
+	size         int64
+	lastModified time.Time
+	hasMetadata  bool
+}
+
+func newHTTPBackend(uri string, cfg Config) (*HTTPBackend, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTTP URI %s: %w", uri, err)
+	}
+
+	return &HTTPBackend{
+		client:   &http.Client{Timeout: 60 * time.Second},
+		baseURL:  &url.URL{Scheme: u.Scheme, Host: u.Host, User: u.User},
+		cfg:      cfg,
+		listings: map[string][]httpEntry{},
+	}, nil
+}
+
+func (h *HTTPBackend) Read(ctx context.Context, dir string) ([]*Item, bool, error) {
+	entries, err := h.listing(ctx, dir)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// First check for noindex files before processing anything else
+	noIndex, skipIndex := skipListing(h.cfg, dir, httpFileNames(entries))
+	if noIndex {
+		return nil, true, nil
+	}
+	if skipIndex {
+		return []*Item{}, false, nil
+	}
+
+	var items []*Item
+	for _, entry := range entries {
+		if shouldSkip(entry.name, itemPath(h.cfg, dir, entry.name), h.cfg.IndexFile, h.cfg.Skips) {
+			continue
+		}
+
+		// Check subdirectories for noindex files. The fetched listing is kept
+		// so that recursing into the subdirectory doesn't request it again.
+		excluded := false
+		if entry.isDir && len(h.cfg.NoIndexFiles) > 0 {
+			subDir := path.Join(dir, entry.name)
+			subEntries, err := h.listing(ctx, subDir)
+			if err != nil {
+				return nil, false, err
+			}
+			h.cache(subDir, subEntries)
+
+			excluded = excludedDir(h.cfg, subDir, httpFileNames(subEntries))
+		}
+
+		items = append(items, &Item{
+			Name:         entry.name,
+			Size:         entry.size,
+			LastModified: entry.lastModified,
+			IsDir:        entry.isDir,
+			HasMetadata:  entry.hasMetadata,
+			NoIndex:      excluded,
+		})
+	}
+
+	return items, false, nil
+}
+
+// ReadFile downloads a file from a directory of the site.
+func (h *HTTPBackend) ReadFile(ctx context.Context, dir, name string) ([]byte, error) {
+	fileURL := *h.baseURL
+	fileURL.Path = path.Join("/", dir, name)
+
+	log.Debugf("Fetching %s", fileURL.Redacted())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "web-indexer")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch %s: %w", fileURL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%s: %w", fileURL.Redacted(), fs.ErrNotExist)
+	default:
+		return nil, fmt.Errorf("unable to fetch %s: %s", fileURL.Redacted(), resp.Status)
+	}
+}
+
+// EnsureDirExists is not supported as HTTP sources are read-only.
+func (h *HTTPBackend) EnsureDirExists(_ context.Context, relativePath string) error {
+	return fmt.Errorf("unable to create %s: %w", relativePath, ErrReadOnlySource)
+}
+
+// Write is not supported as HTTP sources are read-only.
+func (h *HTTPBackend) Write(_ context.Context, data Data, _ string) error {
+	return fmt.Errorf("unable to write index for %s: %w", data.RelativePath, ErrReadOnlySource)
+}
+
+func (h *HTTPBackend) cache(dir string, entries []httpEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.listings[dir] = entries
+}
+
+// listing returns the parsed entries for the directory, using a cached copy
+// if one was fetched earlier.
+func (h *HTTPBackend) listing(ctx context.Context, dir string) ([]httpEntry, error) {
+	h.mu.Lock()
+	entries, ok := h.listings[dir]
+	delete(h.listings, dir)
+	h.mu.Unlock()
+	if ok {
+		return entries, nil
+	}
+
+	dirURL := *h.baseURL
+	dirURL.Path = strings.TrimSuffix(dir, "/") + "/"
+
+	log.Debugf("Fetching listing %s", dirURL.Redacted())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dirURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, text/html;q=0.9")
+	req.Header.Set("User-Agent", "web-indexer")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch listing %s: %w", dirURL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch listing %s: %s", dirURL.Redacted(), resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read listing %s: %w", dirURL.Redacted(), err)
+	}
+
+	// Redirects may change the page location, which relative links resolve
+	// against.
+	pageURL := resp.Request.URL
+
+	isJSON := strings.Contains(resp.Header.Get("Content-Type"), "json")
+	if isJSON || bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		return parseJSONListing(body)
+	}
+
+	return parseHTMLListing(body, pageURL, h.cfg.IndexFile)
+}
+
+// httpFileNames returns the names of the files, but not the directories, in a
+// listing.
+func httpFileNames(entries []httpEntry) []string {
+	var names []string
+	for _, entry := range entries {
+		if !entry.isDir {
+			names = append(names, entry.name)
+		}
+	}
+	return names
+}
+
+// nginxJSONEntry is an entry in nginx's JSON autoindex format.
+type nginxJSONEntry struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	MTime string `json:"mtime"`
+	Size  int64  `json:"size"`
+}
+
+// parseJSONListing parses nginx's "autoindex_format json" output.
+func parseJSONListing(body []byte) ([]httpEntry, error) {
+	var raw []nginxJSONEntry
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("unable to parse JSON listing: %w", err)
+	}
+
+	entries := make([]httpEntry, 0, len(raw))
+	for _, r := range raw {
+		entry := httpEntry{
+			name:  r.Name,
+			isDir: r.Type == "directory",
+			size:  r.Size,
+		}
+		if t, err := time.Parse(time.RFC1123, r.MTime); err == nil {
+			entry.lastModified = t.Local()
+			entry.hasMetadata = true
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// parseHTMLListing extracts entries from an HTML directory listing. Every
+// link that points directly inside the listed directory becomes an entry, and
+// the text that follows it up to the next link or table row is searched for a
+// size and a modification date.
+func parseHTMLListing(body []byte, pageURL *url.URL, indexFile string) ([]httpEntry, error) {
+	type link struct {
+		href string
+		text strings.Builder
+	}
+
+	var links []*link
+	var current *link
+	// The link text is the entry name, which may itself look like a date
+	inAnchor := false
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			if errors.Is(tokenizer.Err(), io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("unable to parse HTML listing: %w", tokenizer.Err())
+		}
+
+		tok := tokenizer.Token()
+		switch {
+		case tt == html.StartTagToken && tok.Data == "a":
+			current = nil
+			inAnchor = true
+			for _, attr := range tok.Attr {
+				if attr.Key == "href" {
+					current = &link{href: attr.Val}
+					links = append(links, current)
+				}
+			}
+		case tt == html.EndTagToken && tok.Data == "a":
+			inAnchor = false
+		case tt == html.EndTagToken && tok.Data == "tr":
+			current = nil
+		case tt == html.TextToken && current != nil && !inAnchor:
+			current.text.WriteString(" ")
+			current.text.WriteString(tok.Data)
+		}
+	}
+
+	dirPath := strings.TrimSuffix(pageURL.Path, "/") + "/"
+
+	var entries []httpEntry
+	seen := map[string]bool{}
+	for _, l := range links {
+		name, isDir, ok := listingEntryName(l.href, pageURL, dirPath, indexFile)
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		entry := httpEntry{name: name, isDir: isDir}
+		entry.size, entry.lastModified, entry.hasMetadata = parseListingMetadata(l.text.String())
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// listingEntryName resolves a link against the listing page and returns the
+// entry name if the link points at a direct child of the listed directory.
+func listingEntryName(href string, pageURL *url.URL, dirPath, indexFile string) (string, bool, bool) {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", false, false
+	}
+
+	// Skip sorting links such as Apache's "?C=N;O=D"
+	if ref.RawQuery != "" {
+		return "", false, false
+	}
+
+	target := pageURL.ResolveReference(ref)
+	if target.Host != pageURL.Host || !strings.HasPrefix(target.Path, dirPath) {
+		return "", false, false
+	}
+
+	rest := strings.TrimPrefix(target.Path, dirPath)
+	if indexFile != "" {
+		// Pages generated with link_to_index link to dir/index.html
+		if trimmed, found := strings.CutSuffix(rest, "/"+indexFile); found {
+			rest = trimmed + "/"
+		}
+	}
+
+	isDir := strings.HasSuffix(rest, "/")
+	rest = strings.TrimSuffix(rest, "/")
+	if rest == "" || rest == "." || rest == ".." || strings.Contains(rest, "/") {
+		return "", false, false
+	}
+
+	return rest, isDir, true
+}
+
+var (
+	isoDateRegexp   = regexp.MustCompile(`\d{4}-\d{2}-\d{2} \d{2}:\d{2}(:\d{2})?( [A-Z]{2,5})?`)
+	nginxDateRegexp = regexp.MustCompile(`\d{2}-[A-Z][a-z]{2}-\d{4} \d{2}:\d{2}`)
+	sizeRegexp      = regexp.MustCompile(`(?:^|\s)(\d+(?:\.\d+)?)\s?([KMGTPEZY]?)(?:i?B)?(?:\s|$)`)
+)
+
+// parseListingMetadata finds the modification date and size in the text that
+// follows a link in a listing. It understands the formats used by Apache,
+// nginx and web-indexer's default date format.
+func parseListingMetadata(text string) (int64, time.Time, bool) {
+	text = strings.Join(strings.Fields(text), " ")
+
+	var lastModified time.Time
+	var found bool
+
+	if match := isoDateRegexp.FindString(text); match != "" {
+		for _, layout := range []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+			if t, err := time.Parse(layout, match); err == nil {
+				lastModified, found = t, true
+				break
+			}
+		}
+		text = strings.Replace(text, match, " ", 1)
+	} else if match := nginxDateRegexp.FindString(text); match != "" {
+		if t, err := time.Parse("02-Jan-2006 15:04", match); err == nil {
+			lastModified, found = t, true
+		}
+		text = strings.Replace(text, match, " ", 1)
+	}
+
+	if !found {
+		return 0, time.Time{}, false
+	}
+
+	var size int64
+	if m := sizeRegexp.FindStringSubmatch(text); m != nil {
+		value, err := strconv.ParseFloat(m[1], 64)
+		if err == nil {
+			exp := strings.Index("KMGTPEZY", m[2]) + 1
+			if m[2] == "" {
+				exp = 0
+			}
+			size = int64(value * math.Pow(1024, float64(exp)))
+		}
+	}
+
+	return size, lastModified.Local(), true
+}
+
+func isHTTPURI(uri string) bool {
+	return strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://")
+}
+
+// httpURIPath returns the path component of an http(s):// URI.
+func httpURIPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid HTTP URI %s: %w", uri, err)
+	}
+
+	if u.Path == "" {
+		return "/", nil
+	}
+
+	return path.Clean(u.Path), nil
+}
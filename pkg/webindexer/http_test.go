@@ -0,0 +1,267 @@
+package webindexer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const apacheListing = `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN">
+<html><head><title>Index of /pub</title></head><body>
+<h1>Index of /pub</h1>
+<table>
+<tr><th><a href="?C=N;O=D">Name</a></th><th><a href="?C=M;O=A">Last modified</a></th>
+<th><a href="?C=S;O=A">Size</a></th></tr>
+<tr><td><a href="/">Parent Directory</a></td><td>&nbsp;</td><td align="right">  - </td></tr>
+<tr><td><a href="docs/">docs/</a></td><td align="right">2024-01-02 10:20  </td><td align="right">  - </td></tr>
+<tr><td><a href="release-1.0.tar.gz">release-1.0.tar.gz</a></td>
+<td align="right">2024-03-04 05:06  </td><td align="right">1.5M</td></tr>
+<tr><td><a href="my%20notes.txt">my notes.txt</a></td>
+<td align="right">2023-12-31 23:59  </td><td align="right">120 </td></tr>
+<tr><td><a href="https://example.org/elsewhere/">elsewhere</a></td><td></td><td></td></tr>
+</table>
+</body></html>`
+
+const nginxListing = `<html>
+<head><title>Index of /pub/</title></head>
+<body>
+<h1>Index of /pub/</h1><hr><pre><a href="../">../</a>
+<a href="docs/">docs/</a>                                              02-Jan-2024 10:20                   -
+<a href="release-1.0.tar.gz">release-1.0.tar.gz</a>                   04-Mar-2024 05:06             1572864
+</pre><hr></body>
+</html>`
+
+const nginxJSONListing = `[
+{ "name":"docs", "type":"directory", "mtime":"Tue, 02 Jan 2024 10:20:00 GMT" },
+{ "name":"release-1.0.tar.gz", "type":"file", "mtime":"Mon, 04 Mar 2024 05:06:00 GMT", "size":1572864 }
+]`
+
+func TestParseHTMLListingApache(t *testing.T) {
+	pageURL, _ := url.Parse("http://mirror.example.com/pub/")
+	entries, err := parseHTMLListing([]byte(apacheListing), pageURL, "index.html")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "docs", entries[0].name)
+	assert.True(t, entries[0].isDir)
+	assert.True(t, entries[0].hasMetadata)
+	assert.Equal(t, int64(0), entries[0].size)
+
+	assert.Equal(t, "release-1.0.tar.gz", entries[1].name)
+	assert.False(t, entries[1].isDir)
+	assert.Equal(t, int64(1572864), entries[1].size)
+	assert.Equal(t, time.Date(2024, 3, 4, 5, 6, 0, 0, time.UTC), entries[1].lastModified.UTC())
+
+	assert.Equal(t, "my notes.txt", entries[2].name)
+	assert.Equal(t, int64(120), entries[2].size)
+}
+
+func TestParseHTMLListingNginx(t *testing.T) {
+	pageURL, _ := url.Parse("http://mirror.example.com/pub/")
+	entries, err := parseHTMLListing([]byte(nginxListing), pageURL, "index.html")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "docs", entries[0].name)
+	assert.True(t, entries[0].isDir)
+	assert.Equal(t, time.Date(2024, 1, 2, 10, 20, 0, 0, time.UTC), entries[0].lastModified.UTC())
+
+	assert.Equal(t, "release-1.0.tar.gz", entries[1].name)
+	assert.Equal(t, int64(1572864), entries[1].size)
+}
+
+func TestParseJSONListing(t *testing.T) {
+	entries, err := parseJSONListing([]byte(nginxJSONListing))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "docs", entries[0].name)
+	assert.True(t, entries[0].isDir)
+	assert.Equal(t, "release-1.0.tar.gz", entries[1].name)
+	assert.Equal(t, int64(1572864), entries[1].size)
+	assert.Equal(t, time.Date(2024, 3, 4, 5, 6, 0, 0, time.UTC), entries[1].lastModified.UTC())
+}
+
+func TestParseListingMetadata(t *testing.T) {
+	tests := []struct {
+		text    string
+		size    int64
+		hasMeta bool
+	}{
+		{"2024-01-02 10:20 1.5M", 1572864, true},
+		{"02-Jan-2024 10:20 1234", 1234, true},
+		{"1.00 KB 2024-01-02 10:20:30 UTC", 1024, true},
+		{"4 B 2024-01-02 10:20:30 UTC", 4, true},
+		{"- 2024-01-02 10:20:30 UTC", 0, true},
+		{"- -", 0, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.text, func(t *testing.T) {
+			size, _, hasMeta := parseListingMetadata(tc.text)
+			assert.Equal(t, tc.size, size)
+			assert.Equal(t, tc.hasMeta, hasMeta)
+		})
+	}
+}
+
+func TestListingEntryName(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/pub/")
+	tests := []struct {
+		href  string
+		name  string
+		isDir bool
+		ok    bool
+	}{
+		{"file.txt", "file.txt", false, true},
+		{"dir/", "dir", true, true},
+		{"dir/index.html", "dir", true, true},
+		{"https://example.com/pub/abs.txt", "abs.txt", false, true},
+		{"../", "", false, false},
+		{"?C=N;O=D", "", false, false},
+		{"dir/nested/file.txt", "", false, false},
+		{"https://other.example.com/pub/file.txt", "", false, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.href, func(t *testing.T) {
+			name, isDir, ok := listingEntryName(tc.href, pageURL, "/pub/", "index.html")
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.name, name)
+			assert.Equal(t, tc.isDir, isDir)
+		})
+	}
+}
+
+// newListingServer serves nginx JSON listings for a small tree.
+func newListingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	pages := map[string]string{
+		"/pub/": `[
+			{"name":"docs","type":"directory","mtime":"Tue, 02 Jan 2024 10:20:00 GMT"},
+			{"name":"private","type":"directory","mtime":"Tue, 02 Jan 2024 10:20:00 GMT"},
+			{"name":"release.tar.gz","type":"file","mtime":"Mon, 04 Mar 2024 05:06:00 GMT","size":2048},
+			{"name":"skip.me","type":"file","mtime":"Mon, 04 Mar 2024 05:06:00 GMT","size":1}
+		]`,
+		"/pub/docs/": `[
+			{"name":"guide.md","type":"file","mtime":"Mon, 04 Mar 2024 05:06:00 GMT","size":512}
+		]`,
+		"/pub/private/": `[
+			{"name":".noindex","type":"file","mtime":"Mon, 04 Mar 2024 05:06:00 GMT","size":0},
+			{"name":"secret.txt","type":"file","mtime":"Mon, 04 Mar 2024 05:06:00 GMT","size":5}
+		]`,
+	}
+
+	var mu sync.Mutex
+	requests := map[string]int{}
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for p, n := range requests {
+			assert.Equal(t, 1, n, "listing %s should only be fetched once", p)
+		}
+	})
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(page))
+	}))
+}
+
+func TestHTTPBackendRead(t *testing.T) {
+	server := newListingServer(t)
+	defer server.Close()
+
+	backend, err := newHTTPBackend(server.URL+"/pub", Config{
+		IndexFile:    "index.html",
+		NoIndexFiles: []string{".noindex"},
+		Skips:        []string{"skip.me"},
+	})
+	require.NoError(t, err)
+
+	items, hasNoIndex, err := backend.Read(t.Context(), "/pub")
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+
+	names := map[string]bool{}
+	for _, item := range items {
+		if !item.NoIndex {
+			names[item.Name] = item.IsDir
+		}
+	}
+	assert.Equal(t, map[string]bool{"docs": true, "release.tar.gz": false}, names)
+
+	// The docs listing was fetched while checking for noindex files and is
+	// served from the cache.
+	items, _, err = backend.Read(t.Context(), "/pub/docs")
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "guide.md", items[0].Name)
+}
+
+func TestHTTPBackendIsReadOnly(t *testing.T) {
+	backend, err := newHTTPBackend("https://example.com/pub", Config{})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, backend.Write(t.Context(), Data{RelativePath: "/"}, ""), ErrReadOnlySource)
+	require.ErrorIs(t, backend.EnsureDirExists(t.Context(), "/"), ErrReadOnlySource)
+
+	_, err = New(Config{
+		Source: "/tmp",
+		Target: "https://example.com/pub",
+		SortBy: "name",
+		Order:  "asc",
+	})
+	require.ErrorIs(t, err, ErrReadOnlySource)
+}
+
+func TestGenerateFromHTTPSource(t *testing.T) {
+	server := newListingServer(t)
+	defer server.Close()
+
+	targetDir := t.TempDir()
+	indexer, err := New(Config{
+		Source:       server.URL + "/pub",
+		Target:       targetDir,
+		Recursive:    true,
+		IndexFile:    "index.html",
+		NoIndexFiles: []string{".noindex"},
+		SortBy:       "name",
+		Order:        "asc",
+		DateFormat:   "2006-01-02",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/pub", indexer.Cfg.BasePath)
+
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+
+	root, err := os.ReadFile(filepath.Join(targetDir, "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(root), "release.tar.gz")
+	assert.Contains(t, string(root), "docs")
+	assert.NotContains(t, string(root), "private")
+
+	docs, err := os.ReadFile(filepath.Join(targetDir, "docs", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(docs), "guide.md")
+
+	assert.NoDirExists(t, filepath.Join(targetDir, "private"))
+}
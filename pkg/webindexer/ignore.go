@@ -0,0 +1,118 @@
+package webindexer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// ignoreLoader reads an ignore file from a directory of the source. dir is
+// the slash-separated path of the directory from the root of the source. It
+// returns an error wrapping fs.ErrNotExist when the file doesn't exist.
+type ignoreLoader func(ctx context.Context, dir, name string) ([]byte, error)
+
+// ignoreCache holds the parsed ignore files of the directories of a source.
+// Ignore files use gitignore syntax and apply to the directory they're in and
+// everything below it, with rules in deeper directories taking precedence.
+// The zero value is ready to use.
+type ignoreCache struct {
+	mu       sync.Mutex
+	patterns map[string][]gitignore.Pattern
+}
+
+// matcher returns a matcher for the entries of dir, built from the ignore
+// files of dir and each of its parents.
+func (c *ignoreCache) matcher(
+	ctx context.Context,
+	names []string,
+	dir string,
+	load ignoreLoader,
+) (gitignore.Matcher, error) {
+	domain := splitPath(dir)
+
+	var patterns []gitignore.Pattern
+	for n := 0; n <= len(domain); n++ {
+		dirPatterns, err := c.dirPatterns(ctx, names, domain[:n], load)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, dirPatterns...)
+	}
+
+	return gitignore.NewMatcher(patterns), nil
+}
+
+// dirPatterns returns the patterns of the ignore files in a single directory,
+// reading them on first use.
+func (c *ignoreCache) dirPatterns(
+	ctx context.Context,
+	names []string,
+	domain []string,
+	load ignoreLoader,
+) ([]gitignore.Pattern, error) {
+	dir := strings.Join(domain, "/")
+
+	c.mu.Lock()
+	patterns, ok := c.patterns[dir]
+	c.mu.Unlock()
+	if ok {
+		return patterns, nil
+	}
+
+	for _, name := range names {
+		content, err := load(ctx, dir, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ignore file %s in /%s: %w", name, dir, err)
+		}
+		patterns = append(patterns, parseIgnoreFile(content, domain)...)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.patterns == nil {
+		c.patterns = map[string][]gitignore.Pattern{}
+	}
+	c.patterns[dir] = patterns
+
+	return patterns, nil
+}
+
+// parseIgnoreFile parses the gitignore patterns of an ignore file in the
+// directory given by domain. Blank lines and comments are ignored.
+func parseIgnoreFile(content []byte, domain []string) []gitignore.Pattern {
+	var patterns []gitignore.Pattern
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, domain))
+	}
+	return patterns
+}
+
+// ignored reports whether the matcher excludes an item, given its path from
+// the root of the source.
+func ignored(m gitignore.Matcher, relativePath string, isDir bool) bool {
+	return m.Match(splitPath(relativePath), isDir)
+}
+
+// splitPath splits a slash-separated path into its elements.
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
@@ -0,0 +1,165 @@
+package webindexer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalBackendReadIgnoreFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		".webindexerignore":         "# Build output\n*.log\nbuild/\n!keep.log\n/drafts\n",
+		"app.log":                   "",
+		"keep.log":                  "",
+		"readme.txt":                "",
+		"drafts/post.md":            "",
+		"build/out.bin":             "",
+		"docs/debug.log":            "",
+		"docs/build":                "",
+		"docs/drafts/post.md":       "",
+		"docs/.webindexerignore":    "!debug.log\nsecret.txt\n",
+		"docs/secret.txt":           "",
+		"docs/guide/secret.txt":     "",
+		"docs/guide/intro.txt":      "",
+		"docs/guide/build/file.txt": "",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0o644))
+	}
+
+	backend := &LocalBackend{
+		path: tempDir,
+		cfg: Config{
+			BasePath:    tempDir,
+			IndexFile:   "index.html",
+			IgnoreFiles: []string{".webindexerignore"},
+		},
+	}
+
+	indexer := Indexer{Cfg: backend.cfg, Source: backend, filters: &itemFilter{}}
+	names := func(dir string) []string {
+		return indexerNames(t, indexer, filepath.Join(tempDir, dir))
+	}
+
+	assert.Equal(t, []string{"docs", "keep.log", "readme.txt"}, names(""))
+	// build is only ignored as a directory and /drafts only at the root
+	assert.Equal(t, []string{"build", "debug.log", "drafts", "guide"}, names("docs"))
+	assert.Equal(t, []string{"intro.txt"}, names("docs/guide"))
+}
+
+func TestS3BackendReadIgnoreFiles(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	backend := &S3Backend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			BasePath:    "files",
+			IndexFile:   "index.html",
+			IgnoreFiles: []string{".webindexerignore"},
+		},
+	}
+
+	object := func(key string) *s3.Object {
+		return &s3.Object{Key: aws.String(key), Size: aws.Int64(1), LastModified: aws.Time(time.Now())}
+	}
+	listing := func(prefix string, output *s3.ListObjectsV2Output) {
+		mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+			return *input.Prefix == prefix
+		})).Return(output, nil)
+	}
+
+	listing("files/docs/", &s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			object("files/docs/.webindexerignore"),
+			object("files/docs/notes.txt"),
+			object("files/docs/debug.log"),
+			object("files/docs/secret.txt"),
+		},
+		CommonPrefixes: []*s3.CommonPrefix{
+			{Prefix: aws.String("files/docs/build/")},
+			{Prefix: aws.String("files/docs/guide/")},
+		},
+	})
+	listing("files/docs/build/", &s3.ListObjectsV2Output{})
+	listing("files/docs/guide/", &s3.ListObjectsV2Output{})
+
+	mockSvc.On("GetObjectWithContext", mock.MatchedBy(func(input *s3.GetObjectInput) bool {
+		return *input.Key == "files/.webindexerignore"
+	})).Return(&s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader("*.log\nbuild/\n"))}, nil).Once()
+	mockSvc.On("GetObjectWithContext", mock.MatchedBy(func(input *s3.GetObjectInput) bool {
+		return *input.Key == "files/docs/.webindexerignore"
+	})).Return(&s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader("secret.txt\n"))}, nil).Once()
+
+	indexer := Indexer{Cfg: backend.cfg, Source: backend, filters: &itemFilter{}}
+	assert.Equal(t, []string{"guide/", "notes.txt"}, indexerNames(t, indexer, "files/docs/"))
+
+	// The ignore files are cached, so reading again doesn't fetch them
+	indexerNames(t, indexer, "files/docs/")
+	mockSvc.AssertNumberOfCalls(t, "GetObjectWithContext", 2)
+}
+
+func TestS3BackendReadIgnoreFilesMissing(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	backend := &S3Backend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			IndexFile:   "index.html",
+			IgnoreFiles: []string{".webindexerignore"},
+		},
+	}
+
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.Anything).Return(&s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			{Key: aws.String("sub/file.txt"), Size: aws.Int64(1), LastModified: aws.Time(time.Now())},
+		},
+	}, nil)
+	mockSvc.On("GetObjectWithContext", mock.MatchedBy(func(input *s3.GetObjectInput) bool {
+		return *input.Key == ".webindexerignore"
+	})).Return(nil, awserr.New(s3.ErrCodeNoSuchKey, "not found", nil)).Once()
+
+	assert.Equal(t, []string{"file.txt"}, filteredNames(t, backend, backend.cfg, "sub/"))
+
+	// Only the parent is fetched, as the listing shows sub/ has no ignore file
+	mockSvc.AssertExpectations(t)
+}
+
+// filteredNames returns the sorted names of the items of a directory of the
+// source after the indexer's filters.
+func filteredNames(t *testing.T, source FileSource, cfg Config, dir string) []string {
+	t.Helper()
+	return indexerNames(t, Indexer{Cfg: cfg, Source: source, filters: &itemFilter{}}, dir)
+}
+
+func indexerNames(t *testing.T, indexer Indexer, dir string) []string {
+	t.Helper()
+
+	items, _, err := indexer.Source.Read(t.Context(), dir)
+	require.NoError(t, err)
+	items, err = indexer.filterItems(t.Context(), dir, items)
+	require.NoError(t, err)
+	return itemNames(items)
+}
+
+func itemNames(items []*Item) []string {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,57 @@
+package webindexer
+
+import (
+	"strings"
+	"sync"
+)
+
+// includedFile reports whether a file is listed when include patterns are
+// set. The file must match an include pattern, and neither it nor any of its
+// parent directories may be skipped or hidden. relativePath is the file's path from the
+// root of the source.
+func includedFile(cfg Config, relativePath string) bool {
+	parts := splitPath(relativePath)
+	if len(parts) == 0 {
+		return false
+	}
+
+	name := parts[len(parts)-1]
+	if !matchPatterns(name, relativePath, cfg.Includes) ||
+		shouldSkip(name, relativePath, cfg.IndexFile, cfg.Skips) ||
+		hiddenItem(cfg, name, relativePath) {
+		return false
+	}
+
+	for n := 1; n < len(parts); n++ {
+		dir := strings.Join(parts[:n], "/")
+		if matchPatterns(parts[n-1], dir, cfg.Skips) || hiddenItem(cfg, parts[n-1], dir) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dirFlags records a flag for directories of a source, such as whether they
+// have a file matching the include patterns anywhere below them, keyed by
+// their path from the root of the source. The zero value is ready to use.
+type dirFlags struct {
+	mu   sync.Mutex
+	dirs map[string]bool
+}
+
+func (c *dirFlags) get(dir string) (matched, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	matched, ok = c.dirs[strings.Trim(dir, "/")]
+	return matched, ok
+}
+
+func (c *dirFlags) set(dir string, matched bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dirs == nil {
+		c.dirs = map[string]bool{}
+	}
+	c.dirs[strings.Trim(dir, "/")] = matched
+}
@@ -0,0 +1,299 @@
+package webindexer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncludedFile(t *testing.T) {
+	cfg := Config{
+		IndexFile: "index.html",
+		Includes:  []string{"*.tar.gz", "*.sha256", "/docs/*.pdf"},
+		Skips:     []string{"old", "*-rc.tar.gz"},
+	}
+
+	tests := []struct {
+		relativePath string
+		want         bool
+	}{
+		{"app.tar.gz", true},
+		{"v1/app.tar.gz.sha256", true},
+		{"v1/readme.txt", false},
+		{"docs/manual.pdf", true},
+		{"v1/docs/manual.pdf", false},
+		{"old/app.tar.gz", false},
+		{"v1/old/app.tar.gz", false},
+		{"v2/app-rc.tar.gz", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, includedFile(cfg, tt.relativePath), tt.relativePath)
+	}
+}
+
+func TestLocalBackendReadIncludes(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{
+		"README.md",
+		"app-1.0.tar.gz",
+		"app-1.0.tar.gz.sha256",
+		"docs/guide.md",
+		"v2/notes.txt",
+		"v2/linux/app_2.0_amd64.deb",
+		"old/app-0.1.tar.gz",
+	} {
+		fullPath := filepath.Join(tempDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+		require.NoError(t, os.WriteFile(fullPath, []byte("test content"), 0o644))
+	}
+
+	backend := &LocalBackend{
+		path: tempDir,
+		cfg: Config{
+			BasePath:  tempDir,
+			IndexFile: "index.html",
+			Includes:  []string{"*.tar.gz", "*.sha256", "*.deb"},
+			Skips:     []string{"old"},
+		},
+	}
+
+	indexer := Indexer{Cfg: backend.cfg, Source: backend, filters: &itemFilter{}}
+	names := func(dir string) []string {
+		return indexerNames(t, indexer, filepath.Join(tempDir, dir))
+	}
+
+	assert.Equal(t, []string{"app-1.0.tar.gz", "app-1.0.tar.gz.sha256", "v2"}, names(""))
+	assert.Equal(t, []string{"linux"}, names("v2"))
+	assert.Equal(t, []string{"app_2.0_amd64.deb"}, names("v2/linux"))
+}
+
+// TestGenerate_IncludesExcludedDirs checks that matching files in directories
+// that aren't indexed don't make their parents listed.
+func TestGenerate_IncludesExcludedDirs(t *testing.T) {
+	tests := map[string]map[string]string{
+		"noindex": {
+			"excluded/private/.noindex": "",
+			"excluded/private/app.deb":  "",
+		},
+		"ignore file": {
+			"excluded/.webindexerignore": "build/\n",
+			"excluded/build/app.deb":     "",
+		},
+		"directory config skips": {
+			"excluded/" + DefaultDirConfigFile: "skips:\n  - old\n",
+			"excluded/old/app.deb":             "",
+		},
+	}
+
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			sourceDir := t.TempDir()
+			targetDir := t.TempDir()
+			files["releases/app.deb"] = ""
+			for name, content := range files {
+				fullPath := filepath.Join(sourceDir, name)
+				require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+				require.NoError(t, os.WriteFile(fullPath, []byte(content), 0o644))
+			}
+
+			indexer, err := New(Config{
+				Source:        sourceDir,
+				Target:        targetDir,
+				Recursive:     true,
+				IndexFile:     "index.html",
+				NoIndexFiles:  []string{".noindex"},
+				IgnoreFiles:   []string{".webindexerignore"},
+				DirConfigFile: DefaultDirConfigFile,
+				Includes:      []string{"*.deb"},
+				SortBy:        "name",
+				Order:         "asc",
+			})
+			require.NoError(t, err)
+
+			_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+			require.NoError(t, err)
+
+			root, err := os.ReadFile(filepath.Join(targetDir, "index.html"))
+			require.NoError(t, err)
+			assert.Contains(t, string(root), "releases")
+			assert.NotContains(t, string(root), "excluded")
+			assert.NoDirExists(t, filepath.Join(targetDir, "excluded"))
+		})
+	}
+}
+
+func TestS3BackendReadIncludes(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	backend := &S3Backend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			BasePath:  "releases",
+			IndexFile: "index.html",
+			Includes:  []string{"*.tar.gz", "*.deb"},
+		},
+	}
+
+	object := func(key string) *s3.Object {
+		return &s3.Object{Key: aws.String(key), Size: aws.Int64(1), LastModified: aws.Time(time.Now())}
+	}
+	listing := func(prefix string, delimited bool, output *s3.ListObjectsV2Output) {
+		mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+			return *input.Prefix == prefix && (input.Delimiter != nil) == delimited
+		})).Return(output, nil)
+	}
+
+	listing("releases/", true, &s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			object("releases/README.md"),
+			object("releases/app-1.0.tar.gz"),
+		},
+		CommonPrefixes: []*s3.CommonPrefix{
+			{Prefix: aws.String("releases/docs/")},
+			{Prefix: aws.String("releases/v2/")},
+		},
+	})
+	listing("releases/", false, &s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			object("releases/README.md"),
+			object("releases/app-1.0.tar.gz"),
+			object("releases/docs/guide.md"),
+			object("releases/v2/notes.txt"),
+			object("releases/v2/linux/app_2.0_amd64.deb"),
+		},
+	})
+	listing("releases/docs/", true, &s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			object("releases/docs/guide.md"),
+		},
+	})
+	listing("releases/v2/", true, &s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			object("releases/v2/notes.txt"),
+		},
+		CommonPrefixes: []*s3.CommonPrefix{
+			{Prefix: aws.String("releases/v2/linux/")},
+		},
+	})
+	listing("releases/v2/linux/", true, &s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			object("releases/v2/linux/app_2.0_amd64.deb"),
+		},
+	})
+
+	indexer := Indexer{Cfg: backend.cfg, Source: backend, filters: &itemFilter{}}
+	assert.Equal(t, []string{"app-1.0.tar.gz", "v2/"}, indexerNames(t, indexer, "releases/"))
+	assert.Equal(t, []string{"linux/"}, indexerNames(t, indexer, "releases/v2/"))
+
+	// The whole source is listed once, when reading the root
+	calls := 0
+	for _, call := range mockSvc.Calls {
+		if input, ok := call.Arguments.Get(0).(*s3.ListObjectsV2Input); ok && input.Delimiter == nil {
+			calls++
+		}
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestS3BackendReadIncludesExcludedDirs(t *testing.T) {
+	keys := []string{
+		"site/private-only/private/.noindex",
+		"site/private-only/private/app.deb",
+		"site/ignored-only/.webindexerignore",
+		"site/ignored-only/build/app.deb",
+		"site/skipped-only/" + DefaultDirConfigFile,
+		"site/skipped-only/old/app.deb",
+		"site/releases/app.deb",
+	}
+
+	mockSvc := new(MockS3Client)
+	backend := &S3Backend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			Source:        "s3://test-bucket/site",
+			Target:        "s3://test-bucket/site",
+			BasePath:      "site",
+			IndexFile:     "index.html",
+			NoIndexFiles:  []string{".noindex"},
+			IgnoreFiles:   []string{".webindexerignore"},
+			DirConfigFile: DefaultDirConfigFile,
+			Includes:      []string{"*.deb"},
+			SortBy:        "name",
+			Order:         "asc",
+		},
+	}
+
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+		return *input.Prefix == "site/" && input.Delimiter == nil
+	})).Return(s3Listing(keys, "site/", false), nil)
+	for _, prefix := range []string{
+		"site/",
+		"site/private-only/",
+		"site/private-only/private/",
+		"site/ignored-only/",
+		"site/ignored-only/build/",
+		"site/skipped-only/",
+		"site/skipped-only/old/",
+		"site/releases/",
+	} {
+		mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+			return *input.Prefix == prefix && input.Delimiter != nil
+		})).Return(s3Listing(keys, prefix, true), nil)
+	}
+
+	object := func(key, content string) {
+		mockSvc.On("GetObjectWithContext", mock.MatchedBy(func(input *s3.GetObjectInput) bool {
+			return *input.Key == key
+		})).Return(&s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(content))}, nil).Once()
+	}
+	object("site/ignored-only/.webindexerignore", "build/\n")
+	object("site/skipped-only/"+DefaultDirConfigFile, "skips:\n  - old\n")
+	mockSvc.On("GetObjectWithContext", mock.Anything).
+		Return(nil, awserr.New(s3.ErrCodeNoSuchKey, "not found", nil))
+
+	indexer := Indexer{Cfg: backend.cfg, Source: backend, filters: &itemFilter{}}
+	assert.Equal(t, []string{"releases/"}, indexerNames(t, indexer, "site/"))
+}
+
+// s3Listing returns the listing of the objects with the given keys under
+// prefix. A delimited listing has the objects directly under prefix and the
+// prefixes of its subdirectories, otherwise it has every object below it.
+func s3Listing(keys []string, prefix string, delimited bool) *s3.ListObjectsV2Output {
+	output := &s3.ListObjectsV2Output{}
+	prefixes := map[string]bool{}
+	for _, key := range keys {
+		name, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+
+		if dir, _, nested := strings.Cut(name, "/"); delimited && nested {
+			if !prefixes[dir] {
+				prefixes[dir] = true
+				output.CommonPrefixes = append(output.CommonPrefixes, &s3.CommonPrefix{
+					Prefix: aws.String(prefix + dir + "/"),
+				})
+			}
+			continue
+		}
+
+		output.Contents = append(output.Contents, &s3.Object{
+			Key:          aws.String(key),
+			Size:         aws.Int64(1),
+			LastModified: aws.Time(time.Now()),
+		})
+	}
+	return output
+}
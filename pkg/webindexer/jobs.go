@@ -0,0 +1,131 @@
+package webindexer
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// Job is a named indexing run from the jobs of a configuration file.
+type Job struct {
+	Name   string
+	Config Config
+}
+
+// ResolveJobs returns the jobs of the configuration, each with its own
+// settings merged over the shared settings of c. When names are given, only
+// those jobs are returned, in the order of the configuration.
+func (c Config) ResolveJobs(names []string) ([]Job, error) {
+	if len(c.Jobs) == 0 {
+		return nil, fmt.Errorf("no jobs are configured")
+	}
+
+	shared := c
+	shared.Jobs = nil
+
+	var jobs []Job
+	seen := map[string]bool{}
+	for n, settings := range c.Jobs {
+		name, _ := settings["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("job %d has no name", n+1)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("job %q is defined more than once", name)
+		}
+		seen[name] = true
+
+		if len(names) > 0 && !slices.Contains(names, name) {
+			continue
+		}
+
+		cfg, err := shared.withJob(settings)
+		if err != nil {
+			return nil, fmt.Errorf("invalid job %q: %w", name, err)
+		}
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid job %q: %w", name, err)
+		}
+
+		jobs = append(jobs, Job{Name: name, Config: cfg})
+	}
+
+	for _, name := range names {
+		if !seen[name] {
+			return nil, fmt.Errorf("unknown job %q", name)
+		}
+	}
+
+	if err := checkJobOutputs(jobs, c.ParallelJobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// checkJobOutputs returns an error if jobs would overwrite each other's report
+// or metrics file, or, when they run at once, each other's index files.
+func checkJobOutputs(jobs []Job, parallel bool) error {
+	targets := map[string]string{}
+	reports := map[string]string{}
+	metrics := map[string]string{}
+	for _, job := range jobs {
+		if other, ok := targets[job.Config.Target]; ok && parallel {
+			return fmt.Errorf("jobs %q and %q write to the same target %s and can't run in parallel",
+				other, job.Name, job.Config.Target)
+		}
+		targets[job.Config.Target] = job.Name
+
+		if other, ok := reports[job.Config.Report]; ok && job.Config.Report != "" {
+			return fmt.Errorf("jobs %q and %q write the same report %s", other, job.Name, job.Config.Report)
+		}
+		reports[job.Config.Report] = job.Name
+
+		if other, ok := metrics[job.Config.MetricsFile]; ok && job.Config.MetricsFile != "" {
+			return fmt.Errorf("jobs %q and %q write the same metrics file %s", other, job.Name, job.Config.MetricsFile)
+		}
+		metrics[job.Config.MetricsFile] = job.Name
+	}
+
+	return nil
+}
+
+// withJob returns the configuration with the settings of a job merged over
+// it. Settings use the same keys as the configuration file.
+func (c Config) withJob(settings map[string]any) (Config, error) {
+	merged := map[string]any{}
+	if err := mapstructure.Decode(c, &merged); err != nil {
+		return Config{}, err
+	}
+
+	for key, value := range settings {
+		switch key {
+		case "name":
+			continue
+		case "jobs":
+			return Config{}, fmt.Errorf("jobs can't be nested")
+		}
+		merged[key] = value
+	}
+
+	var cfg Config
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+		ErrorUnused:      true,
+		WeaklyTypedInput: true,
+		Result:           &cfg,
+	})
+	if err != nil {
+		return Config{}, err
+	}
+
+	if err := decoder.Decode(merged); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
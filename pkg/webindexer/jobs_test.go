@@ -0,0 +1,153 @@
+package webindexer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newJobsConfig(jobs ...map[string]any) Config {
+	return Config{
+		IndexFile: "index.html",
+		SortBy:    "name",
+		Order:     "asc",
+		Title:     "Files",
+		Skips:     []string{".git"},
+		Jobs:      jobs,
+	}
+}
+
+func TestResolveJobs(t *testing.T) {
+	cfg := newJobsConfig(
+		map[string]any{"name": "docs", "source": "/srv/docs", "target": "/var/www/docs"},
+		map[string]any{
+			"name":      "releases",
+			"source":    "s3://bucket/releases",
+			"target":    "s3://bucket/releases",
+			"title":     "Releases",
+			"order":     "desc",
+			"recursive": true,
+			"skips":     []any{"*.tmp"},
+			"timeout":   "10m",
+		},
+	)
+
+	jobs, err := cfg.ResolveJobs(nil)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+
+	assert.Equal(t, "docs", jobs[0].Name)
+	assert.Equal(t, "/srv/docs", jobs[0].Config.Source)
+	assert.Equal(t, "Files", jobs[0].Config.Title)
+	assert.Equal(t, []string{".git"}, jobs[0].Config.Skips)
+	assert.Empty(t, jobs[0].Config.Jobs)
+
+	assert.Equal(t, "releases", jobs[1].Name)
+	assert.Equal(t, "s3://bucket/releases", jobs[1].Config.Source)
+	assert.Equal(t, "Releases", jobs[1].Config.Title)
+	assert.Equal(t, "desc", jobs[1].Config.Order)
+	assert.Equal(t, "name", jobs[1].Config.SortBy)
+	assert.True(t, jobs[1].Config.Recursive)
+	assert.Equal(t, []string{"*.tmp"}, jobs[1].Config.Skips)
+	assert.Equal(t, 10*time.Minute, jobs[1].Config.Timeout)
+
+	// The shared configuration isn't changed by the jobs
+	assert.Equal(t, "Files", cfg.Title)
+}
+
+func TestResolveJobs_Select(t *testing.T) {
+	cfg := newJobsConfig(
+		map[string]any{"name": "a", "source": "/a", "target": "/a"},
+		map[string]any{"name": "b", "source": "/b", "target": "/b"},
+		map[string]any{"name": "c", "source": "/c", "target": "/c"},
+	)
+
+	jobs, err := cfg.ResolveJobs([]string{"c", "a"})
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "a", jobs[0].Name)
+	assert.Equal(t, "c", jobs[1].Name)
+
+	_, err = cfg.ResolveJobs([]string{"d"})
+	assert.EqualError(t, err, `unknown job "d"`)
+}
+
+func TestResolveJobs_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		jobs []map[string]any
+		want string
+	}{
+		{
+			"no name",
+			[]map[string]any{{"source": "/a", "target": "/a"}},
+			"job 1 has no name",
+		},
+		{
+			"duplicate name",
+			[]map[string]any{
+				{"name": "a", "source": "/a", "target": "/a"},
+				{"name": "a", "source": "/b", "target": "/b"},
+			},
+			`job "a" is defined more than once`,
+		},
+		{
+			"unknown key",
+			[]map[string]any{{"name": "a", "source": "/a", "target": "/a", "tilte": "Typo"}},
+			"tilte",
+		},
+		{
+			"nested jobs",
+			[]map[string]any{{"name": "a", "source": "/a", "target": "/a", "jobs": []any{}}},
+			"jobs can't be nested",
+		},
+		{
+			"invalid setting",
+			[]map[string]any{{"name": "a", "source": "/a", "target": "/a", "sort_by": "size"}},
+			"sort_by must be one of",
+		},
+		{
+			"missing source",
+			[]map[string]any{{"name": "a", "target": "/a"}},
+			`invalid job "a"`,
+		},
+		{
+			"same report",
+			[]map[string]any{
+				{"name": "a", "source": "/a", "target": "/a", "report": "report.json"},
+				{"name": "b", "source": "/b", "target": "/b", "report": "report.json"},
+			},
+			`jobs "a" and "b" write the same report report.json`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newJobsConfig(tt.jobs...).ResolveJobs(nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestResolveJobs_SameTarget(t *testing.T) {
+	cfg := newJobsConfig(
+		map[string]any{"name": "a", "source": "/a", "target": "/www"},
+		map[string]any{"name": "b", "source": "/b", "target": "/www"},
+	)
+
+	// Jobs run one at a time may share a target
+	_, err := cfg.ResolveJobs(nil)
+	require.NoError(t, err)
+
+	cfg.ParallelJobs = true
+	_, err = cfg.ResolveJobs(nil)
+	assert.EqualError(t, err, `jobs "a" and "b" write to the same target /www and can't run in parallel`)
+}
+
+func TestResolveJobs_None(t *testing.T) {
+	_, err := newJobsConfig().ResolveJobs(nil)
+	assert.EqualError(t, err, "no jobs are configured")
+}
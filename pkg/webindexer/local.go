@@ -0,0 +1,257 @@
+package webindexer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+type LocalBackend struct {
+	path string
+	cfg  Config
+}
+
+var (
+	_ FileSource    = &LocalBackend{}
+	_ ManifestStore = &LocalBackend{}
+	_ IndexComparer = &LocalBackend{}
+	_ IndexReader   = &LocalBackend{}
+	_ IndexPruner   = &LocalBackend{}
+	_ FileReader    = &LocalBackend{}
+)
+
+func (l *LocalBackend) Read(ctx context.Context, path string) ([]*Item, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	var items []*Item
+	log.Debugf("Listing files in %s", path)
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to read source path %s: %w", path, err)
+	}
+
+	// First check for noindex files before processing anything else
+	noIndex, skipIndex := skipListing(l.cfg, path, dirEntryFileNames(files))
+	if noIndex {
+		return nil, true, nil
+	}
+	if skipIndex {
+		// Return empty items but mark as not having noindex file
+		// This will prevent indexing this directory but still include it in the parent
+		return []*Item{}, false, nil
+	}
+
+	// Process all other files
+	for _, file := range files {
+		if shouldSkip(file.Name(), itemPath(l.cfg, path, file.Name()), l.cfg.IndexFile, l.cfg.Skips) {
+			continue
+		}
+
+		fullPath := filepath.Join(path, file.Name())
+		entry, ok, err := l.resolveEntry(path, file)
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			continue
+		}
+		stat := entry.info
+
+		// If it's a directory, check if it contains a noindex file before adding it
+		excluded := false
+		if stat.IsDir() {
+			subFiles, err := os.ReadDir(fullPath)
+			if err != nil {
+				return nil, false, fmt.Errorf("unable to read directory %s: %w", fullPath, err)
+			}
+
+			excluded = excludedDir(l.cfg, fullPath, dirEntryFileNames(subFiles))
+		}
+
+		itemName := file.Name()
+		item := &Item{
+			Name:         itemName,
+			Size:         stat.Size(),
+			LastModified: stat.ModTime(),
+			IsDir:        stat.IsDir(),
+			HasMetadata:  true,
+			IsSymlink:    entry.isSymlink,
+			LinkTarget:   entry.linkTarget,
+			NoIndex:      excluded,
+		}
+
+		items = append(items, item)
+	}
+
+	return items, false, nil
+}
+
+// ReadFile reads a file from a directory of the source.
+func (l *LocalBackend) ReadFile(_ context.Context, dir, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(dir, name)) // #nosec
+}
+
+func (l *LocalBackend) EnsureDirExists(ctx context.Context, relativePath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	localPath := filepath.Join(l.cfg.Target, relativePath)
+	if err := os.MkdirAll(localPath, 0o750); err != nil {
+		return fmt.Errorf("failed to ensure directory exists %s: %w", localPath, err)
+	}
+	log.Debugf("Ensured directory exists: %s", localPath)
+	return nil
+}
+
+func (l *LocalBackend) Write(ctx context.Context, data Data, content string) error {
+	localPath := l.targetDir(data)
+	if err := os.MkdirAll(localPath, 0o750); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", localPath, err)
+	}
+
+	filePath := filepath.Join(localPath, l.cfg.IndexFile)
+	if err := writeFileAtomic(ctx, filePath, content); err != nil {
+		return err
+	}
+
+	log.Infof("Generated %s", filePath)
+	return nil
+}
+
+// IndexUnchanged compares the hash of the existing index file with the hash
+// of the content.
+func (l *LocalBackend) IndexUnchanged(_ context.Context, data Data, content string) (bool, error) {
+	filePath := filepath.Join(l.targetDir(data), l.cfg.IndexFile)
+	file, err := os.Open(filePath) // #nosec
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	existing := sha256.New()
+	if _, err := io.Copy(existing, file); err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(existing.Sum(nil), sha256Sum(content)), nil
+}
+
+// ReadIndex reads the existing index file for data.
+func (l *LocalBackend) ReadIndex(_ context.Context, data Data) ([]byte, error) {
+	return os.ReadFile(filepath.Join(l.targetDir(data), l.cfg.IndexFile))
+}
+
+// targetDir returns the directory of the target that the index for data is
+// written to.
+func (l *LocalBackend) targetDir(data Data) string {
+	prefix := data.RelativePath
+	prefix = strings.TrimPrefix(prefix, l.cfg.BasePath)
+
+	// Remove any leading slashes to avoid creating unnecessary subdirectories
+	prefix = strings.TrimPrefix(prefix, "/")
+
+	// For the root directory, don't create an additional subdirectory
+	if prefix == "" || prefix == "/" {
+		return l.cfg.Target
+	}
+
+	return filepath.Join(l.cfg.Target, prefix)
+}
+
+// writeFileAtomic writes the content to a temporary file next to filePath and
+// renames it into place, so an interrupted write never leaves a partial file
+// behind.
+func writeFileAtomic(ctx context.Context, filePath, content string) error {
+	dir, name := filepath.Split(filePath)
+	file, err := os.CreateTemp(dir, "."+name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := file.Name()
+
+	err = func() error {
+		defer file.Close()
+		if err := file.Chmod(0o644); err != nil {
+			return err
+		}
+		if _, err := file.WriteString(content); err != nil {
+			return err
+		}
+		return file.Close()
+	}()
+	if err == nil {
+		// Don't replace the existing file once generation has been canceled
+		err = ctx.Err()
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+// ReadManifest reads the manifest from the root of the target directory.
+func (l *LocalBackend) ReadManifest(_ context.Context, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(l.cfg.Target, name))
+}
+
+// WriteManifest writes the manifest to the root of the target directory.
+func (l *LocalBackend) WriteManifest(ctx context.Context, name string, content []byte) error {
+	filePath := filepath.Join(l.cfg.Target, name)
+	log.Debugf("Writing manifest %s", filePath)
+	return writeFileAtomic(ctx, filePath, string(content))
+}
+
+// ListIndexes walks the target directory for index files.
+func (l *LocalBackend) ListIndexes(ctx context.Context) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(l.cfg.Target, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != l.cfg.IndexFile {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.cfg.Target, filepath.Dir(filePath))
+		if err != nil {
+			return err
+		}
+		dirs = append(dirs, indexDir(filepath.ToSlash(rel)))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dirs, nil
+}
+
+// DeleteIndex removes the index file from the directory of the target.
+func (l *LocalBackend) DeleteIndex(_ context.Context, dir string) error {
+	filePath := filepath.Join(l.cfg.Target, filepath.FromSlash(dir), l.cfg.IndexFile)
+	log.Infof("Removing %s", filePath)
+	return os.Remove(filePath)
+}
@@ -1,6 +1,7 @@
 package webindexer
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -38,7 +39,7 @@ func TestLocalBackendRead(t *testing.T) {
 	}
 
 	// Test the Read function
-	items, hasNoIndex, err := localBackend.Read(tempDir)
+	items, hasNoIndex, err := localBackend.Read(t.Context(), tempDir)
 	if err != nil {
 		t.Errorf("Failed to read directory: %v", err)
 	}
@@ -56,6 +57,45 @@ func TestLocalBackendRead(t *testing.T) {
 	}
 }
 
+func TestLocalBackendReadSkipPatterns(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{
+		"keep.txt",
+		"cache.tmp",
+		".gitignore",
+		"projects/private/secret.txt",
+		"projects/public/notes.txt",
+		"projects/public/node_modules/pkg.js",
+	} {
+		fullPath := filepath.Join(tempDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+		require.NoError(t, os.WriteFile(fullPath, []byte("test content"), 0o644))
+	}
+
+	localBackend := LocalBackend{
+		path: tempDir,
+		cfg: Config{
+			BasePath:  tempDir,
+			IndexFile: "index.html",
+			Skips:     []string{"*.tmp", ".git*", "**/node_modules", "/projects/private/**"},
+		},
+	}
+
+	names := func(dir string) []string {
+		items, _, err := localBackend.Read(t.Context(), filepath.Join(tempDir, dir))
+		require.NoError(t, err)
+		var names []string
+		for _, item := range items {
+			names = append(names, item.Name)
+		}
+		return names
+	}
+
+	assert.ElementsMatch(t, []string{"keep.txt", "projects"}, names(""))
+	assert.ElementsMatch(t, []string{"public"}, names("projects"))
+	assert.ElementsMatch(t, []string{"notes.txt"}, names("projects/public"))
+}
+
 func TestLocalBackendReadWithNoIndex(t *testing.T) {
 	// Setup temporary directory
 	tempDir, err := os.MkdirTemp("", "test")
@@ -83,7 +123,7 @@ func TestLocalBackendReadWithNoIndex(t *testing.T) {
 	}
 
 	// Test reading directory with noindex file
-	items, hasNoIndex, err := localBackend.Read(tempDir)
+	items, hasNoIndex, err := localBackend.Read(t.Context(), tempDir)
 	require.NoError(t, err)
 	assert.True(t, hasNoIndex)
 	assert.Empty(t, items)
@@ -121,13 +161,17 @@ func TestLocalBackendReadSubdirWithNoIndex(t *testing.T) {
 	}
 
 	// Test reading parent directory
-	items, hasNoIndex, err := localBackend.Read(tempDir)
+	items, hasNoIndex, err := localBackend.Read(t.Context(), tempDir)
 	require.NoError(t, err)
 	assert.False(t, hasNoIndex)
-	assert.Len(t, items, 1) // Should only contain file1.txt, not subdir
+	// The subdirectory is only returned to be reported as skipped
+	require.Len(t, items, 2)
+	for _, item := range items {
+		assert.Equal(t, item.IsDir, item.NoIndex, item.Name)
+	}
 
 	// Test reading subdirectory with noindex file
-	items, hasNoIndex, err = localBackend.Read(subDir)
+	items, hasNoIndex, err = localBackend.Read(t.Context(), subDir)
 	require.NoError(t, err)
 	assert.True(t, hasNoIndex)
 	assert.Empty(t, items)
@@ -155,7 +199,7 @@ func TestLocalBackendWrite(t *testing.T) {
 	content := "<html>Test Content</html>"
 
 	// Execute the Write method
-	err = localBackend.Write(data, content)
+	err = localBackend.Write(t.Context(), data, content)
 	require.NoError(t, err, "Failed to write content")
 
 	// Verify the file and its content
@@ -168,3 +212,71 @@ func TestLocalBackendWrite(t *testing.T) {
 
 	assert.Equal(t, strings.TrimSpace(content), strings.TrimSpace(string(readContent)), "File content does not match")
 }
+
+func TestLocalBackendWriteCanceled(t *testing.T) {
+	targetDir := t.TempDir()
+	localBackend := LocalBackend{
+		cfg: Config{
+			Target:    targetDir,
+			IndexFile: "index.html",
+		},
+	}
+
+	filePath := filepath.Join(targetDir, "index.html")
+	require.NoError(t, os.WriteFile(filePath, []byte("previous"), 0o644))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	err := localBackend.Write(ctx, Data{RelativePath: "/"}, "<html>new</html>")
+	require.ErrorIs(t, err, context.Canceled)
+
+	// The existing index is untouched and no temporary file is left behind
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "previous", string(content))
+
+	entries, err := os.ReadDir(targetDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "index.html")
+
+	require.NoError(t, writeFileAtomic(t.Context(), filePath, "first"))
+	require.NoError(t, writeFileAtomic(t.Context(), filePath, "second"))
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(content))
+
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+}
+
+func TestLocalBackendIndexUnchanged(t *testing.T) {
+	targetDir := t.TempDir()
+	localBackend := LocalBackend{
+		cfg: Config{
+			Target:    targetDir,
+			IndexFile: "index.html",
+		},
+	}
+	data := Data{RelativePath: "/sub"}
+
+	unchanged, err := localBackend.IndexUnchanged(t.Context(), data, "content")
+	require.NoError(t, err)
+	assert.False(t, unchanged, "a missing index is changed")
+
+	require.NoError(t, localBackend.Write(t.Context(), data, "content"))
+
+	unchanged, err = localBackend.IndexUnchanged(t.Context(), data, "content")
+	require.NoError(t, err)
+	assert.True(t, unchanged)
+
+	unchanged, err = localBackend.IndexUnchanged(t.Context(), data, "other content")
+	require.NoError(t, err)
+	assert.False(t, unchanged)
+}
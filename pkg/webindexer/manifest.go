@@ -0,0 +1,143 @@
+package webindexer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+
+	"github.com/charmbracelet/log"
+)
+
+// DefaultManifestFile is the name of the incremental manifest when none is
+// configured.
+const DefaultManifestFile = ".web-indexer-manifest.json"
+
+// manifestVersion is bumped whenever the fingerprint format changes, which
+// causes every directory to be regenerated once.
+const manifestVersion = 1
+
+// ManifestStore is implemented by targets that can persist the manifest used
+// for incremental generation. ReadManifest returns an error wrapping
+// fs.ErrNotExist if the target has no manifest yet.
+type ManifestStore interface {
+	ReadManifest(ctx context.Context, name string) ([]byte, error)
+	WriteManifest(ctx context.Context, name string, content []byte) error
+}
+
+// manifestFile is the format of the manifest stored at the target.
+type manifestFile struct {
+	Version int `json:"version"`
+	// Directories maps each directory, relative to the target, to the
+	// fingerprint of its last written index.
+	Directories map[string]string `json:"directories"`
+}
+
+// manifest tracks the fingerprints of the previous run and those recorded
+// during the current run. Only directories indexed in the current run are
+// saved, so removed directories drop out of the manifest.
+type manifest struct {
+	store ManifestStore
+	name  string
+
+	mu       sync.Mutex
+	previous map[string]string
+	current  map[string]string
+}
+
+func manifestFileName(cfg Config) string {
+	if cfg.ManifestFile != "" {
+		return cfg.ManifestFile
+	}
+	return DefaultManifestFile
+}
+
+// loadManifest reads the manifest from the store. A missing or outdated
+// manifest is treated as empty.
+func loadManifest(ctx context.Context, store ManifestStore, name string) (*manifest, error) {
+	m := &manifest{
+		store:    store,
+		name:     name,
+		previous: map[string]string{},
+		current:  map[string]string{},
+	}
+
+	content, err := store.ReadManifest(ctx, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Infof("No manifest %s found at the target, indexing everything", name)
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read manifest %s: %w", name, err)
+	}
+
+	var file manifestFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("unable to parse manifest %s: %w", name, err)
+	}
+
+	if file.Version != manifestVersion {
+		log.Infof("Manifest %s is from a different version, indexing everything", name)
+		return m, nil
+	}
+
+	if file.Directories != nil {
+		m.previous = file.Directories
+	}
+
+	return m, nil
+}
+
+// unchanged reports whether the directory's index was last written with the
+// same fingerprint.
+func (m *manifest) unchanged(dir, fingerprint string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.previous[dir] == fingerprint
+}
+
+// record stores the fingerprint of the directory's current index.
+func (m *manifest) record(dir, fingerprint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.current[dir] = fingerprint
+}
+
+// save writes the fingerprints recorded during this run to the store.
+func (m *manifest) save(ctx context.Context) error {
+	m.mu.Lock()
+	content, err := json.MarshalIndent(manifestFile{
+		Version:     manifestVersion,
+		Directories: m.current,
+	}, "", "  ")
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := m.store.WriteManifest(ctx, m.name, content); err != nil {
+		return fmt.Errorf("unable to write manifest %s: %w", m.name, err)
+	}
+
+	return nil
+}
+
+// fingerprint identifies everything that goes into a rendered index: the
+// template, the template data (which reflects the listing and the relevant
+// configuration) and whether the output is minified.
+func fingerprint(templStr string, minify bool, data Data) (string, error) {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%t\x00", manifestVersion, templStr, minify)
+	h.Write(content)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
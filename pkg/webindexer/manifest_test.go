@@ -0,0 +1,174 @@
+package webindexer
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// markIndexes replaces every generated index with a marker so that a later
+// run shows which ones were rewritten.
+func markIndexes(t *testing.T, targetDir string) {
+	t.Helper()
+
+	for name := range readTree(t, targetDir) {
+		if filepath.Base(name) == "index.html" {
+			require.NoError(t, os.WriteFile(filepath.Join(targetDir, name), []byte("marker"), 0o644))
+		}
+	}
+}
+
+func rewrittenIndexes(t *testing.T, targetDir string) []string {
+	t.Helper()
+
+	var rewritten []string
+	for name, content := range readTree(t, targetDir) {
+		if filepath.Base(name) == "index.html" && content != "marker" {
+			rewritten = append(rewritten, filepath.ToSlash(name))
+		}
+	}
+	return rewritten
+}
+
+func TestGenerate_Incremental(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := t.TempDir()
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, name := range []string{"a/one.txt", "a/deep/two.txt", "b/three.txt"} {
+		p := filepath.Join(sourceDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(name), 0o644))
+		require.NoError(t, os.Chtimes(p, modTime, modTime))
+		require.NoError(t, os.Chtimes(filepath.Dir(p), modTime, modTime))
+	}
+	require.NoError(t, os.Chtimes(filepath.Join(sourceDir, "a"), modTime, modTime))
+
+	cfg := Config{
+		Source:      sourceDir,
+		Target:      targetDir,
+		Recursive:   true,
+		Incremental: true,
+		IndexFile:   "index.html",
+		SortBy:      "name",
+		Order:       "asc",
+		DateFormat:  "2006-01-02 15:04:05 MST",
+	}
+	indexer, err := New(cfg)
+	require.NoError(t, err)
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(targetDir, DefaultManifestFile))
+	require.NoError(t, err)
+	var manifest manifestFile
+	require.NoError(t, json.Unmarshal(content, &manifest))
+	assert.Equal(t, manifestVersion, manifest.Version)
+	assert.Equal(t, []string{"/", "/a", "/a/deep", "/b"}, slices.Sorted(maps.Keys(manifest.Directories)))
+
+	// Nothing changed, so nothing is rewritten
+	markIndexes(t, targetDir)
+	indexer, err = New(cfg)
+	require.NoError(t, err)
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+	assert.Empty(t, rewrittenIndexes(t, targetDir))
+
+	// A changed file rewrites its directory and the parents whose aggregated
+	// size changed
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "a/deep/two.txt"), []byte("much longer content"), 0o644))
+	require.NoError(t, os.Chtimes(filepath.Join(sourceDir, "a/deep/two.txt"), modTime, modTime))
+	indexer, err = New(cfg)
+	require.NoError(t, err)
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"index.html", "a/index.html", "a/deep/index.html"}, rewrittenIndexes(t, targetDir))
+
+	// A configuration change rewrites everything
+	markIndexes(t, targetDir)
+	cfg.Title = "Files"
+	indexer, err = New(cfg)
+	require.NoError(t, err)
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+	assert.Len(t, rewrittenIndexes(t, targetDir), 4)
+}
+
+func TestGenerate_IncrementalSkipsManifest(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.txt"), []byte("x"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DefaultManifestFile), []byte("{}"), 0o644))
+
+	indexer, err := New(Config{
+		Source:      dir,
+		Target:      dir,
+		Recursive:   true,
+		Incremental: true,
+		IndexFile:   "index.html",
+		SortBy:      "name",
+		Order:       "asc",
+		DateFormat:  "2006-01-02 15:04:05 MST",
+	})
+	require.NoError(t, err)
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "file.txt")
+	assert.NotContains(t, string(content), DefaultManifestFile)
+}
+
+func TestGenerate_IncrementalUnsupportedTarget(t *testing.T) {
+	indexer := Indexer{
+		Cfg:    Config{Target: "mock://target", Incremental: true},
+		Source: new(MockSource),
+		Target: new(MockSource),
+	}
+
+	_, err := indexer.Generate(t.Context(), nil, "/")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "incremental mode is not supported")
+}
+
+func TestS3BackendManifest(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	backend := S3Backend{
+		svc: mockSvc,
+		cfg: Config{Target: "s3://test-bucket/site", BasePath: "/"},
+	}
+
+	mockSvc.On("GetObjectWithContext", mock.Anything).
+		Return(nil, awserr.New(s3.ErrCodeNoSuchKey, "not found", nil)).Once()
+	_, err := backend.ReadManifest(t.Context(), DefaultManifestFile)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	mockSvc.On("PutObjectWithContext", mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+		body, _ := io.ReadAll(input.Body)
+		return *input.Bucket == "test-bucket" &&
+			*input.Key == "site/"+DefaultManifestFile &&
+			string(body) == `{"version":1}`
+	})).Return(&s3.PutObjectOutput{}, nil)
+	require.NoError(t, backend.WriteManifest(t.Context(), DefaultManifestFile, []byte(`{"version":1}`)))
+
+	mockSvc.On("GetObjectWithContext", mock.MatchedBy(func(input *s3.GetObjectInput) bool {
+		return *input.Key == "site/"+DefaultManifestFile
+	})).Return(&s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(`{"version":1}`))}, nil)
+	content, err := backend.ReadManifest(t.Context(), DefaultManifestFile)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version":1}`, string(content))
+
+	mockSvc.AssertExpectations(t)
+}
@@ -0,0 +1,224 @@
+package webindexer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const metricLastSuccess = "web_indexer_last_success_timestamp_seconds"
+
+// WriteMetricsFile writes Prometheus metrics about a run of Generate to a
+// file for node_exporter's textfile collector. runErr is the error returned
+// by Generate. The series of a job are labeled with its name, so that jobs
+// can write their files to the same directory. The file is replaced
+// atomically, and the last success timestamp is carried over from the
+// previous file when the run failed.
+func WriteMetricsFile(path, job string, result *Result, runErr error, cfg Config) error {
+	lastSuccess := readLastSuccess(path, job)
+
+	var b strings.Builder
+	writeMetrics(&b, job, result, runErr, cfg, time.Now(), lastSuccess)
+
+	if err := writeFileAtomic(context.Background(), path, b.String()); err != nil {
+		return fmt.Errorf("unable to write metrics file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// readLastSuccess returns the last success timestamp of a job from an existing
+// metrics file, or zero if there is none.
+func readLastSuccess(path, job string) float64 {
+	file, err := os.Open(path) // #nosec
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		value, found := strings.CutPrefix(scanner.Text(), metricSeries(job, metricLastSuccess)+" ")
+		if !found {
+			continue
+		}
+		timestamp, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0
+		}
+		return timestamp
+	}
+
+	return 0
+}
+
+func writeMetrics(
+	w io.Writer,
+	job string,
+	result *Result,
+	runErr error,
+	cfg Config,
+	now time.Time,
+	lastSuccess float64,
+) {
+	if result == nil {
+		result = &Result{}
+	}
+
+	success := 0
+	if runErr == nil {
+		success = 1
+		lastSuccess = float64(now.UnixMilli()) / 1000
+	}
+
+	gauge := func(name, help string) {
+		fmt.Fprintf(w, "# HELP %[1]s %[2]s\n# TYPE %[1]s gauge\n", name, help)
+	}
+	sample := func(value any, name string, labels ...string) {
+		fmt.Fprintf(w, "%s %v\n", metricSeries(job, name, labels...), value)
+	}
+
+	gauge("web_indexer_last_run_timestamp_seconds", "Time the last run finished.")
+	sample(formatFloat(float64(now.UnixMilli())/1000), "web_indexer_last_run_timestamp_seconds")
+
+	gauge("web_indexer_last_run_success", "Whether the last run succeeded.")
+	sample(success, "web_indexer_last_run_success")
+
+	if lastSuccess > 0 {
+		gauge(metricLastSuccess, "Time the last successful run finished.")
+		sample(formatFloat(lastSuccess), metricLastSuccess)
+	}
+
+	gauge("web_indexer_duration_seconds", "Duration of the last run.")
+	sample(formatFloat(result.Duration.Seconds()), "web_indexer_duration_seconds")
+
+	gauge("web_indexer_directories", "Directories visited in the last run by status.")
+	for _, status := range []struct {
+		name  DirStatus
+		count int64
+	}{
+		{DirWritten, result.Written},
+		{DirUnchanged, result.Unchanged},
+		{DirSkipped, result.Skipped},
+		{DirFailed, result.Failed},
+	} {
+		sample(status.count, "web_indexer_directories", "status", string(status.name))
+	}
+
+	gauge("web_indexer_files", "Files listed in the last run.")
+	sample(result.Files, "web_indexer_files")
+
+	gauge("web_indexer_bytes", "Total size of the files listed in the last run.")
+	sample(result.Bytes, "web_indexer_bytes")
+
+	gauge("web_indexer_pruned", "Stale index files removed in the last run.")
+	sample(result.Pruned, "web_indexer_pruned")
+
+	gauge("web_indexer_errors", "Errors in the last run by backend and operation.")
+	errs := countErrors(runErr, backendName(cfg.Source), backendName(cfg.Target))
+	for _, key := range sortedErrorKeys(errs) {
+		sample(errs[key], "web_indexer_errors", "backend", key.backend, "op", key.op)
+	}
+}
+
+// metricSeries formats a series from a metric name and label name and value
+// pairs. The series of a job are labeled with its name first.
+func metricSeries(job, name string, labels ...string) string {
+	if job != "" {
+		labels = append([]string{"job", job}, labels...)
+	}
+	if len(labels) == 0 {
+		return name
+	}
+
+	pairs := make([]string, 0, len(labels)/2)
+	for n := 0; n+1 < len(labels); n += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", labels[n], labels[n+1]))
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+type errorKey struct {
+	backend string
+	op      string
+}
+
+// countErrors counts the failed directories of a run by backend. Reads fail
+// at the source and writes at the target. Other errors, such as an invalid
+// template, are counted with an "other" backend and operation.
+func countErrors(runErr error, source, target string) map[errorKey]int {
+	errs := map[errorKey]int{
+		{source, "read"}:  0,
+		{target, "write"}: 0,
+	}
+
+	var dirErrs []*DirError
+	var failed *GenerateError
+	var dirErr *DirError
+	switch {
+	case runErr == nil:
+	case errors.As(runErr, &failed):
+		dirErrs = failed.Failures
+	case errors.As(runErr, &dirErr):
+		dirErrs = []*DirError{dirErr}
+	default:
+		errs[errorKey{"other", "other"}]++
+	}
+
+	for _, err := range dirErrs {
+		backend := target
+		if err.Op == "read" {
+			backend = source
+		}
+		errs[errorKey{backend, err.Op}]++
+	}
+
+	return errs
+}
+
+func sortedErrorKeys(errs map[errorKey]int) []errorKey {
+	keys := make([]errorKey, 0, len(errs))
+	for key := range errs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].backend != keys[b].backend {
+			return keys[a].backend < keys[b].backend
+		}
+		return keys[a].op < keys[b].op
+	})
+	return keys
+}
+
+// backendName names the backend used for a source or target URI.
+func backendName(uri string) string {
+	switch {
+	case isS3URI(uri):
+		return "s3"
+	case isGCSURI(uri):
+		return "gcs"
+	case isAzureURI(uri):
+		return "azure"
+	case isSFTPURI(uri):
+		return "sftp"
+	case isHTTPURI(uri):
+		return "http"
+	case isGitURI(uri):
+		return "git"
+	case isArchivePath(uri):
+		return "archive"
+	default:
+		return "local"
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
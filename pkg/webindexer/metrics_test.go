@@ -0,0 +1,125 @@
+package webindexer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	result := &Result{
+		Directories: 4,
+		Written:     2,
+		Unchanged:   1,
+		Failed:      1,
+		Files:       10,
+		Bytes:       2048,
+		Duration:    1500 * time.Millisecond,
+	}
+	runErr := &GenerateError{Failures: []*DirError{
+		{Path: "/a", Op: "read", Err: errors.New("broken")},
+	}}
+	cfg := Config{Source: "/srv/files", Target: "s3://bucket/files"}
+
+	var b strings.Builder
+	writeMetrics(&b, "", result, runErr, cfg, time.Unix(1700000000, 0), 1690000000.5)
+
+	assert.Equal(t, `# HELP web_indexer_last_run_timestamp_seconds Time the last run finished.
+# TYPE web_indexer_last_run_timestamp_seconds gauge
+web_indexer_last_run_timestamp_seconds 1700000000
+# HELP web_indexer_last_run_success Whether the last run succeeded.
+# TYPE web_indexer_last_run_success gauge
+web_indexer_last_run_success 0
+# HELP web_indexer_last_success_timestamp_seconds Time the last successful run finished.
+# TYPE web_indexer_last_success_timestamp_seconds gauge
+web_indexer_last_success_timestamp_seconds 1690000000.5
+# HELP web_indexer_duration_seconds Duration of the last run.
+# TYPE web_indexer_duration_seconds gauge
+web_indexer_duration_seconds 1.5
+# HELP web_indexer_directories Directories visited in the last run by status.
+# TYPE web_indexer_directories gauge
+web_indexer_directories{status="written"} 2
+web_indexer_directories{status="unchanged"} 1
+web_indexer_directories{status="skipped"} 0
+web_indexer_directories{status="failed"} 1
+# HELP web_indexer_files Files listed in the last run.
+# TYPE web_indexer_files gauge
+web_indexer_files 10
+# HELP web_indexer_bytes Total size of the files listed in the last run.
+# TYPE web_indexer_bytes gauge
+web_indexer_bytes 2048
+# HELP web_indexer_pruned Stale index files removed in the last run.
+# TYPE web_indexer_pruned gauge
+web_indexer_pruned 0
+# HELP web_indexer_errors Errors in the last run by backend and operation.
+# TYPE web_indexer_errors gauge
+web_indexer_errors{backend="local",op="read"} 1
+web_indexer_errors{backend="s3",op="write"} 0
+`, b.String())
+}
+
+func TestWriteMetricsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "web_indexer.prom")
+	cfg := Config{Source: "/srv/files", Target: "/srv/files"}
+
+	require.NoError(t, WriteMetricsFile(path, "", &Result{Written: 1}, nil, cfg))
+	lastSuccess := readLastSuccess(path, "")
+	assert.Positive(t, lastSuccess)
+
+	// A failed run keeps the last success timestamp
+	require.NoError(t, WriteMetricsFile(path, "", &Result{}, errors.New("invalid template"), cfg))
+	assert.InDelta(t, lastSuccess, readLastSuccess(path, ""), 0)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "web_indexer_last_run_success 0\n")
+	assert.Contains(t, string(content), `web_indexer_errors{backend="other",op="other"} 1`)
+}
+
+func TestWriteMetrics_Job(t *testing.T) {
+	var b strings.Builder
+	cfg := Config{Source: "/srv/files", Target: "/srv/files"}
+	writeMetrics(&b, "mirror", &Result{Written: 1}, nil, cfg, time.Unix(1700000000, 0), 0)
+
+	// Jobs writing to the same textfile directory don't share series
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			assert.Contains(t, line, `{job="mirror"`, line)
+		}
+	}
+	assert.Contains(t, b.String(), `web_indexer_directories{job="mirror",status="written"} 1`)
+	assert.Contains(t, b.String(), `web_indexer_errors{job="mirror",backend="local",op="read"} 0`)
+}
+
+func TestWriteMetricsFile_Job(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mirror.prom")
+	cfg := Config{Source: "/srv/files", Target: "/srv/files"}
+
+	require.NoError(t, WriteMetricsFile(path, "mirror", &Result{Written: 1}, nil, cfg))
+	lastSuccess := readLastSuccess(path, "mirror")
+	assert.Positive(t, lastSuccess)
+
+	require.NoError(t, WriteMetricsFile(path, "mirror", &Result{}, errors.New("invalid template"), cfg))
+	assert.InDelta(t, lastSuccess, readLastSuccess(path, "mirror"), 0)
+}
+
+func TestBackendName(t *testing.T) {
+	for uri, want := range map[string]string{
+		"/srv/files":                   "local",
+		"s3://bucket/path":             "s3",
+		"gs://bucket/path":             "gcs",
+		"az://container/path":          "azure",
+		"sftp://host/path":             "sftp",
+		"https://example.com/pub/":     "http",
+		"git+file:///repo?ref=main":    "git",
+		"/srv/releases/release.tar.gz": "archive",
+	} {
+		assert.Equal(t, want, backendName(uri), uri)
+	}
+}
@@ -0,0 +1,109 @@
+package webindexer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/charmbracelet/log"
+)
+
+// generatorMarker is included in the index files rendered by the built-in
+// themes. Only index files that have it are pruned, so hand-written ones are
+// left alone.
+const generatorMarker = `<meta name="generator" content="web-indexer">`
+
+// IndexPruner is implemented by targets that can find and remove index files.
+// Directories are relative to the target and start with a slash, with "/"
+// being the root of the target.
+type IndexPruner interface {
+	// ListIndexes returns every directory of the target that contains an
+	// index file.
+	ListIndexes(ctx context.Context) ([]string, error)
+	// DeleteIndex removes the index file from the directory.
+	DeleteIndex(ctx context.Context, dir string) error
+}
+
+// indexedDirs records the directories that have an index file after the
+// current run, whether it was written or left unchanged.
+type indexedDirs struct {
+	mu   sync.Mutex
+	dirs map[string]bool
+}
+
+func newIndexedDirs() *indexedDirs {
+	return &indexedDirs{dirs: map[string]bool{}}
+}
+
+func (d *indexedDirs) add(relativePath string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dirs[indexDir(relativePath)] = true
+}
+
+func (d *indexedDirs) has(dir string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dirs[indexDir(dir)]
+}
+
+// indexDir normalizes a directory relative to the target.
+func indexDir(relativePath string) string {
+	return path.Clean("/" + relativePath)
+}
+
+// prune removes the index files from directories of the target that weren't
+// indexed in this run, such as directories that were removed from the source
+// or gained a noindex file. Index files without the generator marker weren't
+// written by web-indexer and are kept.
+func (i Indexer) prune(ctx context.Context) error {
+	pruner, ok := i.Target.(IndexPruner)
+	reader, readable := i.Target.(IndexReader)
+	if !ok || !readable {
+		return fmt.Errorf("pruning is not supported for target %s", i.Cfg.Target)
+	}
+
+	dirs, err := pruner.ListIndexes(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to list index files to prune: %w", err)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		if i.indexed.has(dir) {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		content, err := reader.ReadIndex(ctx, Data{RelativePath: dir})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read index file in %s: %w", dir, err)
+		}
+		if !bytes.Contains(content, []byte(generatorMarker)) {
+			log.Debugf("Not pruning index file in %s, it wasn't generated by web-indexer", dir)
+			continue
+		}
+
+		log.Infof("Pruning stale index file in %s", dir)
+		if err := pruner.DeleteIndex(ctx, dir); err != nil {
+			return fmt.Errorf("unable to prune index file in %s: %w", dir, err)
+		}
+
+		if i.stats != nil {
+			i.stats.addPruned()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package webindexer
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_Prune(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := t.TempDir()
+	for _, dir := range []string{"keep", "removed/nested", "private"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, dir), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(sourceDir, dir, "file.txt"), []byte("content"), 0o644))
+	}
+
+	cfg := Config{
+		Source:       sourceDir,
+		Target:       targetDir,
+		Recursive:    true,
+		Prune:        true,
+		IndexFile:    "index.html",
+		NoIndexFiles: []string{".noindex"},
+		SortBy:       "name",
+		Order:        "asc",
+		DateFormat:   "2006-01-02 15:04:05 MST",
+	}
+	indexer, err := New(cfg)
+	require.NoError(t, err)
+	result, err := indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"index.html",
+		"keep/index.html",
+		"private/index.html",
+		"removed/index.html",
+		"removed/nested/index.html",
+	}, slices.Sorted(maps.Keys(readTree(t, targetDir))))
+	assert.Zero(t, result.Pruned)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(sourceDir, "removed")))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "private", ".noindex"), nil, 0o644))
+	// Unrelated files in the target are left alone
+	require.NoError(t, os.WriteFile(filepath.Join(targetDir, "removed", "other.html"), nil, 0o644))
+
+	// A dry run lists the stale index files without removing them
+	cfg.DryRun = true
+	indexer, err = New(cfg)
+	require.NoError(t, err)
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+	var deleted []string
+	for _, planned := range indexer.Target.(*DryRunTarget).Planned() {
+		if planned.Status == PlanDelete {
+			deleted = append(deleted, planned.Path)
+		}
+	}
+	assert.Equal(t, []string{"/private/index.html", "/removed/index.html", "/removed/nested/index.html"}, deleted)
+	assert.Len(t, readTree(t, targetDir), 6)
+
+	cfg.DryRun = false
+	indexer, err = New(cfg)
+	require.NoError(t, err)
+	result, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"index.html",
+		"keep/index.html",
+		"removed/other.html",
+	}, slices.Sorted(maps.Keys(readTree(t, targetDir))))
+	assert.Equal(t, int64(3), result.Pruned)
+}
+
+func TestGenerate_PruneKeepsHandWrittenIndexes(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := t.TempDir()
+	for _, dir := range []string{"keep", "private"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, dir), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(sourceDir, dir, "file.txt"), []byte("content"), 0o644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "private", ".noindex"), nil, 0o644))
+
+	// Index files that web-indexer didn't generate aren't stale indexes
+	for _, dir := range []string{"private", "manual"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(targetDir, dir), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(targetDir, dir, "index.html"), []byte("<html></html>"), 0o644))
+	}
+
+	indexer, err := New(Config{
+		Source:       sourceDir,
+		Target:       targetDir,
+		Recursive:    true,
+		Prune:        true,
+		IndexFile:    "index.html",
+		NoIndexFiles: []string{".noindex"},
+		SortBy:       "name",
+		Order:        "asc",
+		DateFormat:   "2006-01-02 15:04:05 MST",
+	})
+	require.NoError(t, err)
+	result, err := indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+	assert.Zero(t, result.Pruned)
+	assert.Equal(t, []string{
+		"index.html",
+		"keep/index.html",
+		"manual/index.html",
+		"private/index.html",
+	}, slices.Sorted(maps.Keys(readTree(t, targetDir))))
+}
+
+func TestGenerate_PruneUnsupportedTarget(t *testing.T) {
+	indexer := Indexer{
+		Cfg:    Config{Target: "mock://target", Recursive: true, Prune: true},
+		Source: new(MockSource),
+		Target: new(MockSource),
+	}
+	indexer.Source.(*MockSource).On("Read", "/").Return([]*Item{}, false, nil)
+	indexer.Target.(*MockSource).On("EnsureDirExists", "/").Return(nil)
+
+	_, err := indexer.Generate(t.Context(), nil, "/")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pruning is not supported")
+}
+
+func TestS3BackendPrune(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	backend := S3Backend{
+		svc: mockSvc,
+		cfg: Config{Target: "s3://test-bucket/site", BasePath: "/", IndexFile: "index.html"},
+	}
+
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+		return *input.Bucket == "test-bucket" && *input.Prefix == "site/" && input.Delimiter == nil
+	})).Return(&s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			{Key: aws.String("site/index.html"), LastModified: aws.Time(time.Now())},
+			{Key: aws.String("site/a/file.txt"), LastModified: aws.Time(time.Now())},
+			{Key: aws.String("site/a/b/index.html"), LastModified: aws.Time(time.Now())},
+		},
+	}, nil)
+
+	dirs, err := backend.ListIndexes(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/", "/a/b"}, dirs)
+
+	mockSvc.On("DeleteObjectWithContext", &s3.DeleteObjectInput{
+		Bucket: aws.String("test-bucket"),
+		Key:    aws.String("site/a/b/index.html"),
+	}).Return(&s3.DeleteObjectOutput{}, nil)
+	require.NoError(t, backend.DeleteIndex(t.Context(), "/a/b"))
+
+	mockSvc.AssertExpectations(t)
+}
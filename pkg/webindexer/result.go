@@ -0,0 +1,132 @@
+package webindexer
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DirStatus is the outcome of indexing a single directory.
+type DirStatus string
+
+const (
+	// DirWritten means the index file was created or updated.
+	DirWritten DirStatus = "written"
+	// DirUnchanged means the index file already had the generated content.
+	DirUnchanged DirStatus = "unchanged"
+	// DirSkipped means no index file was generated because the directory has
+	// a noindex or skipindex file, or is empty.
+	DirSkipped DirStatus = "skipped"
+	// DirFailed means the directory couldn't be read or its index written.
+	DirFailed DirStatus = "failed"
+)
+
+// DirResult describes how a single directory was indexed.
+type DirResult struct {
+	// Path is the directory relative to the source.
+	Path   string    `json:"path"`
+	Status DirStatus `json:"status"`
+	// Files and Bytes count the files listed directly in the directory.
+	Files int64 `json:"files"`
+	Bytes int64 `json:"bytes"`
+	// Duration includes the time spent indexing subdirectories.
+	Duration time.Duration `json:"-"`
+}
+
+// MarshalJSON encodes the duration in seconds.
+func (d DirResult) MarshalJSON() ([]byte, error) {
+	type dirResult DirResult
+	return json.Marshal(struct {
+		dirResult
+		DurationSeconds float64 `json:"duration_seconds"`
+	}{dirResult(d), d.Duration.Seconds()})
+}
+
+// Result summarizes a call to Generate.
+type Result struct {
+	// Directories is the number of directories visited.
+	Directories int64 `json:"directories"`
+	// Written, Unchanged, Skipped and Failed count the directories visited
+	// by their status.
+	Written   int64 `json:"written"`
+	Unchanged int64 `json:"unchanged"`
+	Skipped   int64 `json:"skipped"`
+	Failed    int64 `json:"failed"`
+	// Pruned is the number of stale index files removed from the target.
+	Pruned int64 `json:"pruned"`
+	// Files and Bytes count the files listed in all directories.
+	Files    int64         `json:"files"`
+	Bytes    int64         `json:"bytes"`
+	Duration time.Duration `json:"-"`
+	// PerDirectory is sorted by path.
+	PerDirectory []DirResult `json:"per_directory"`
+}
+
+// MarshalJSON encodes the duration in seconds.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	return json.Marshal(struct {
+		result
+		DurationSeconds float64 `json:"duration_seconds"`
+	}{result(r), r.Duration.Seconds()})
+}
+
+// runStats is shared by the copies of an Indexer used during generation.
+type runStats struct {
+	start time.Time
+
+	mu     sync.Mutex
+	dirs   []DirResult
+	pruned int64
+}
+
+func newRunStats() *runStats {
+	return &runStats{start: time.Now()}
+}
+
+func (s *runStats) addDir(dir DirResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dirs = append(s.dirs, dir)
+}
+
+func (s *runStats) addPruned() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruned++
+}
+
+// result totals the directories indexed so far.
+func (s *runStats) result() *Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := &Result{
+		Pruned:       s.pruned,
+		Duration:     time.Since(s.start),
+		PerDirectory: make([]DirResult, len(s.dirs)),
+	}
+	copy(r.PerDirectory, s.dirs)
+	sort.SliceStable(r.PerDirectory, func(a, b int) bool {
+		return r.PerDirectory[a].Path < r.PerDirectory[b].Path
+	})
+
+	for _, dir := range r.PerDirectory {
+		r.Directories++
+		r.Files += dir.Files
+		r.Bytes += dir.Bytes
+		switch dir.Status {
+		case DirWritten:
+			r.Written++
+		case DirUnchanged:
+			r.Unchanged++
+		case DirSkipped:
+			r.Skipped++
+		case DirFailed:
+			r.Failed++
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,96 @@
+package webindexer
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_Result(t *testing.T) {
+	fsys := fstest.MapFS{
+		"file.txt":            &fstest.MapFile{Data: []byte("12345")},
+		"docs/a.txt":          &fstest.MapFile{Data: []byte("123")},
+		"docs/b.txt":          &fstest.MapFile{Data: []byte("1234")},
+		"skipped/.skipindex":  &fstest.MapFile{},
+		"skipped/hidden.txt":  &fstest.MapFile{Data: []byte("12")},
+		"private/.noindex":    &fstest.MapFile{},
+		"private/secret.txt":  &fstest.MapFile{Data: []byte("1")},
+		"docs/empty/.gitkeep": &fstest.MapFile{},
+	}
+
+	cfg := Config{
+		Target:         t.TempDir(),
+		Recursive:      true,
+		IndexFile:      "index.html",
+		NoIndexFiles:   []string{".noindex"},
+		SkipIndexFiles: []string{".skipindex"},
+		Skips:          []string{".gitkeep"},
+		SortBy:         "name",
+		Order:          "asc",
+		DateFormat:     "2006-01-02 15:04:05 MST",
+	}
+	indexer, err := NewWithSource(cfg, NewFSSource(fsys, cfg))
+	require.NoError(t, err)
+
+	result, err := indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(5), result.Directories)
+	assert.Equal(t, int64(2), result.Written)
+	assert.Equal(t, int64(3), result.Skipped)
+	assert.Zero(t, result.Failed)
+	assert.Equal(t, int64(3), result.Files)
+	assert.Equal(t, int64(12), result.Bytes)
+	assert.Positive(t, result.Duration)
+
+	var paths []string
+	for _, dir := range result.PerDirectory {
+		paths = append(paths, dir.Path)
+	}
+	assert.Equal(t, []string{"/", "/docs", "/docs/empty", "/private", "/skipped"}, paths)
+	assert.Equal(t, DirResult{Path: "/docs", Status: DirWritten, Files: 2, Bytes: 7},
+		withoutDuration(result.PerDirectory[1]))
+	assert.Equal(t, DirSkipped, result.PerDirectory[2].Status)
+	// Directories with a noindex file are reported without being read
+	assert.Equal(t, DirResult{Path: "/private", Status: DirSkipped}, result.PerDirectory[3])
+	assert.Equal(t, DirSkipped, result.PerDirectory[4].Status)
+}
+
+func withoutDuration(dir DirResult) DirResult {
+	dir.Duration = 0
+	return dir
+}
+
+func TestResultJSON(t *testing.T) {
+	result := Result{
+		Directories: 1,
+		Written:     1,
+		Files:       2,
+		Bytes:       3,
+		Duration:    1500 * time.Millisecond,
+		PerDirectory: []DirResult{
+			{Path: "/", Status: DirWritten, Files: 2, Bytes: 3, Duration: 250 * time.Millisecond},
+		},
+	}
+
+	content, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"directories": 1,
+		"written": 1,
+		"unchanged": 0,
+		"skipped": 0,
+		"failed": 0,
+		"pruned": 0,
+		"files": 2,
+		"bytes": 3,
+		"duration_seconds": 1.5,
+		"per_directory": [
+			{"path": "/", "status": "written", "files": 2, "bytes": 3, "duration_seconds": 0.25}
+		]
+	}`, string(content))
+}
@@ -0,0 +1,420 @@
+package webindexer
+
+import (
+	"context"
+	"crypto/md5" // #nosec
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/charmbracelet/log"
+)
+
+type S3Backend struct {
+	svc      S3API
+	bucket   string
+	cfg      Config
+	includes dirFlags
+}
+
+type S3API interface {
+	ListObjectsV2PagesWithContext(
+		ctx aws.Context,
+		input *s3.ListObjectsV2Input,
+		fn func(*s3.ListObjectsV2Output, bool) bool,
+		opts ...request.Option,
+	) error
+	PutObjectWithContext(
+		ctx aws.Context,
+		input *s3.PutObjectInput,
+		opts ...request.Option,
+	) (*s3.PutObjectOutput, error)
+	GetObjectWithContext(
+		ctx aws.Context,
+		input *s3.GetObjectInput,
+		opts ...request.Option,
+	) (*s3.GetObjectOutput, error)
+	HeadObjectWithContext(
+		ctx aws.Context,
+		input *s3.HeadObjectInput,
+		opts ...request.Option,
+	) (*s3.HeadObjectOutput, error)
+	DeleteObjectWithContext(
+		ctx aws.Context,
+		input *s3.DeleteObjectInput,
+		opts ...request.Option,
+	) (*s3.DeleteObjectOutput, error)
+}
+
+var (
+	_ FileSource      = &S3Backend{}
+	_ ManifestStore   = &S3Backend{}
+	_ IndexComparer   = &S3Backend{}
+	_ IndexReader     = &S3Backend{}
+	_ IndexPruner     = &S3Backend{}
+	_ FileReader      = &S3Backend{}
+	_ includeSearcher = &S3Backend{}
+)
+
+// s3ChecksumMetadata is the user metadata key holding the SHA-256 of an
+// uploaded index file. It is used when the ETag isn't an MD5 of the content,
+// such as for objects encrypted with SSE-KMS.
+const s3ChecksumMetadata = "Web-Indexer-Sha256"
+
+func (s *S3Backend) Read(ctx context.Context, prefix string) ([]*Item, bool, error) {
+	prefix = objectPrefix(prefix)
+
+	log.Debugf("Listing objects in %s/%s", s.bucket, prefix)
+
+	contents, commonPrefixes, err := s.listObjects(ctx, prefix)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to list S3 objects: %w", err)
+	}
+
+	// First check for noindex files or skipindex files before processing anything else
+	noIndex, skipIndex := skipListing(s.cfg, s.bucket+"/"+prefix, objectFileNames(contents))
+	if noIndex {
+		return nil, true, nil
+	}
+	if skipIndex {
+		// Return empty items but mark as not having noindex file
+		// This will prevent indexing this directory but still include it in the parent
+		return []*Item{}, false, nil
+	}
+
+	var items []*Item
+	// Process all other files
+	for _, content := range contents {
+		// Get the relative name by removing the prefix
+		itemName := strings.TrimPrefix(*content.Key, prefix)
+		relativePath := itemPath(s.cfg, prefix, itemName)
+
+		if shouldSkip(itemName, relativePath, s.cfg.IndexFile, s.cfg.Skips) {
+			continue
+		}
+
+		item := &Item{
+			Name:         itemName,
+			Size:         *content.Size,
+			LastModified: content.LastModified.Local(),
+			IsDir:        false,
+			HasMetadata:  true,
+		}
+
+		items = append(items, item)
+	}
+
+	// Only process directories if we haven't found a noindex file
+	for _, commonPrefix := range commonPrefixes {
+		log.Debugf("Found common prefix: %s", *commonPrefix.Prefix)
+
+		dirName := strings.TrimPrefix(*commonPrefix.Prefix, prefix)
+		relativePath := itemPath(s.cfg, prefix, dirName)
+		if matchPatterns(dirName, relativePath, s.cfg.Skips) {
+			continue
+		}
+
+		// Check if this prefix contains a noindex file
+		subContents, _, err := s.listObjects(ctx, *commonPrefix.Prefix)
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to list S3 objects in prefix %s: %w", *commonPrefix.Prefix, err)
+		}
+
+		item := &Item{
+			Name:    dirName,
+			IsDir:   true,
+			NoIndex: excludedDir(s.cfg, s.bucket+"/"+*commonPrefix.Prefix, objectFileNames(subContents)),
+		}
+		items = append(items, item)
+	}
+
+	return items, false, nil
+}
+
+// objectFileNames returns the base names of the objects in a listing.
+func objectFileNames(contents []*s3.Object) []string {
+	names := make([]string, 0, len(contents))
+	for _, content := range contents {
+		names = append(names, filepath.Base(*content.Key))
+	}
+	return names
+}
+
+// ReadFile reads an object from a directory of the source.
+func (s *S3Backend) ReadFile(ctx context.Context, prefix, name string) ([]byte, error) {
+	return s.getObject(ctx, s.bucket, objectPrefix(prefix)+name)
+}
+
+// objectPrefix normalizes the prefix of a directory for object keys, without a
+// leading slash and with a trailing slash unless it's the root.
+func objectPrefix(prefix string) string {
+	// Ensure the prefix has a trailing slash for object keys
+	if !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
+	// Remove leading slash for object keys
+	return strings.TrimPrefix(prefix, "/")
+}
+
+// hasIncludedFiles reports whether the subdirectory name of prefix has an
+// object matching the include patterns anywhere below it. Ignore files and
+// noindex files aren't taken into account, so the indexer still reads the
+// directories it reports true for.
+func (s *S3Backend) hasIncludedFiles(ctx context.Context, prefix, name string) (bool, error) {
+	prefix = objectPrefix(prefix)
+	if err := s.findIncludedFiles(ctx, prefix); err != nil {
+		return false, err
+	}
+
+	matched, _ := s.includes.get(itemPath(s.cfg, prefix, name))
+	return matched, nil
+}
+
+// findIncludedFiles records which directories under prefix have an object
+// matching the include patterns anywhere below them. All the objects under
+// prefix are listed at once, so this is only done for the first directory
+// searched, usually the root of the source, and the result is reused for its
+// subdirectories.
+func (s *S3Backend) findIncludedFiles(ctx context.Context, prefix string) error {
+	dir := itemPath(s.cfg, prefix, "")
+	if _, ok := s.includes.get(dir); ok {
+		return nil
+	}
+
+	dirs := map[string]bool{dir: false}
+	err := s.svc.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, content := range page.Contents {
+			relativePath := itemPath(s.cfg, prefix, strings.TrimPrefix(aws.StringValue(content.Key), prefix))
+			matched := includedFile(s.cfg, relativePath)
+			for parent := path.Dir(relativePath); parent != "." && parent != dir; parent = path.Dir(parent) {
+				dirs[parent] = dirs[parent] || matched
+			}
+			dirs[dir] = dirs[dir] || matched
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("unable to list S3 objects: %w", err)
+	}
+
+	for d, matched := range dirs {
+		s.includes.set(d, matched)
+	}
+
+	return nil
+}
+
+// listObjects returns every object and common prefix directly under the given
+// prefix, following continuation tokens until all pages have been read.
+func (s *S3Backend) listObjects(ctx context.Context, prefix string) ([]*s3.Object, []*s3.CommonPrefix, error) {
+	var contents []*s3.Object
+	var commonPrefixes []*s3.CommonPrefix
+
+	req := &s3.ListObjectsV2Input{
+		Bucket:    aws.String(s.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	}
+
+	err := s.svc.ListObjectsV2PagesWithContext(ctx, req, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		contents = append(contents, page.Contents...)
+		commonPrefixes = append(commonPrefixes, page.CommonPrefixes...)
+		return true
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return contents, commonPrefixes, nil
+}
+
+// EnsureDirExists is a no-op for S3 as directories are implicit.
+func (s *S3Backend) EnsureDirExists(_ context.Context, relativePath string) error {
+	log.Debugf("EnsureDirExists called for S3 (no-op): %s/%s", s.bucket, relativePath)
+	// S3 directories are created implicitly by object keys.
+	// We could potentially check if the bucket exists here if needed.
+	return nil
+}
+
+func (s *S3Backend) Write(ctx context.Context, data Data, content string) error {
+	bucket, target := s.targetKey(data.RelativePath, s.cfg.IndexFile)
+
+	strReader := strings.NewReader(content)
+	size := humanizeBytes(int64(strReader.Len()))
+	log.Infof("Uploading %s to %s/%s", size, bucket, target)
+
+	_, err := s.svc.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(target),
+		Body:            aws.ReadSeekCloser(strReader),
+		ContentType:     aws.String("text/html"),
+		ContentEncoding: aws.String("utf-8"),
+		Metadata: map[string]*string{
+			s3ChecksumMetadata: aws.String(hex.EncodeToString(sha256Sum(content))),
+		},
+	})
+	return err
+}
+
+// IndexUnchanged compares the existing object's checksum metadata, or its
+// ETag if it has none, with the content.
+func (s *S3Backend) IndexUnchanged(ctx context.Context, data Data, content string) (bool, error) {
+	bucket, target := s.targetKey(data.RelativePath, s.cfg.IndexFile)
+
+	out, err := s.svc.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(target),
+	})
+	var aerr awserr.Error
+	if errors.As(err, &aerr) && (aerr.Code() == "NotFound" || aerr.Code() == s3.ErrCodeNoSuchKey) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for key, value := range out.Metadata {
+		if strings.EqualFold(key, s3ChecksumMetadata) && value != nil {
+			return *value == hex.EncodeToString(sha256Sum(content)), nil
+		}
+	}
+
+	if out.ETag == nil {
+		return false, nil
+	}
+
+	md5Sum := md5.Sum([]byte(content)) // #nosec
+	return strings.Trim(*out.ETag, `"`) == hex.EncodeToString(md5Sum[:]), nil
+}
+
+// ReadManifest downloads the manifest from the root of the target.
+func (s *S3Backend) ReadManifest(ctx context.Context, name string) ([]byte, error) {
+	bucket, key := s.targetKey("/", name)
+	return s.getObject(ctx, bucket, key)
+}
+
+// ReadIndex downloads the existing index file for data.
+func (s *S3Backend) ReadIndex(ctx context.Context, data Data) ([]byte, error) {
+	bucket, key := s.targetKey(data.RelativePath, s.cfg.IndexFile)
+	return s.getObject(ctx, bucket, key)
+}
+
+// getObject downloads an object, returning an error wrapping fs.ErrNotExist
+// if it doesn't exist.
+func (s *S3Backend) getObject(ctx context.Context, bucket, key string) ([]byte, error) {
+	out, err := s.svc.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	var aerr awserr.Error
+	if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, fmt.Errorf("%s/%s: %w", bucket, key, fs.ErrNotExist)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	return io.ReadAll(out.Body)
+}
+
+// WriteManifest uploads the manifest to the root of the target.
+func (s *S3Backend) WriteManifest(ctx context.Context, name string, content []byte) error {
+	bucket, key := s.targetKey("/", name)
+	log.Debugf("Uploading manifest to %s/%s", bucket, key)
+
+	_, err := s.svc.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        aws.ReadSeekCloser(strings.NewReader(string(content))),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
+
+// ListIndexes lists every index file under the target prefix.
+func (s *S3Backend) ListIndexes(ctx context.Context) ([]string, error) {
+	bucket, root := s.targetKey("/", "")
+	prefix := root
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	var dirs []string
+	err := s.svc.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, content := range page.Contents {
+			key := aws.StringValue(content.Key)
+			if path.Base(key) != s.cfg.IndexFile {
+				continue
+			}
+			dirs = append(dirs, indexDir(strings.TrimPrefix(path.Dir(key), root)))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list S3 objects: %w", err)
+	}
+
+	return dirs, nil
+}
+
+// DeleteIndex deletes the index file from the directory of the target.
+func (s *S3Backend) DeleteIndex(ctx context.Context, dir string) error {
+	bucket, key := s.targetKey(dir, s.cfg.IndexFile)
+	log.Infof("Deleting %s/%s", bucket, key)
+
+	_, err := s.svc.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// targetKey returns the bucket and key of a file in the given directory of
+// the target.
+func (s *S3Backend) targetKey(relativePath, name string) (string, string) {
+	bucket, target := uriToBucketAndPrefix(s.cfg.Target)
+	target = strings.TrimPrefix(target, s.cfg.BasePath)
+	return bucket, filepath.Join(target, relativePath, name)
+}
+
+func isS3URI(uri string) bool {
+	return strings.HasPrefix(uri, "s3://")
+}
+
+// isBucketURI reports whether the URI refers to an object storage bucket or
+// container, where the first path segment is the bucket name.
+func isBucketURI(uri string) bool {
+	return isS3URI(uri) || isGCSURI(uri) || isAzureURI(uri)
+}
+
+// uriToBucketAndPrefix splits an object storage URI such as s3://bucket/prefix
+// or gs://bucket/prefix into its bucket and prefix.
+func uriToBucketAndPrefix(uri string) (string, string) {
+	if _, rest, found := strings.Cut(uri, "://"); found {
+		uri = rest
+	}
+	uriParts := strings.SplitN(uri, "/", 2)
+
+	if len(uriParts) == 1 {
+		return uriParts[0], ""
+	}
+
+	return uriParts[0], uriParts[1]
+}
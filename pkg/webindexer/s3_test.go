@@ -0,0 +1,521 @@
+package webindexer
+
+import (
+	"crypto/md5" // #nosec
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockS3Client struct {
+	mock.Mock
+}
+
+// ListObjectsV2PagesWithContext feeds the mocked response to fn. The mock may return
+// either a single page or a slice of pages to simulate a truncated listing.
+func (m *MockS3Client) ListObjectsV2PagesWithContext(
+	_ aws.Context,
+	input *s3.ListObjectsV2Input,
+	fn func(*s3.ListObjectsV2Output, bool) bool,
+	_ ...request.Option,
+) error {
+	args := m.Called(input)
+
+	var pages []*s3.ListObjectsV2Output
+	switch resp := args.Get(0).(type) {
+	case *s3.ListObjectsV2Output:
+		pages = []*s3.ListObjectsV2Output{resp}
+	case []*s3.ListObjectsV2Output:
+		pages = resp
+	}
+
+	for i, page := range pages {
+		if !fn(page, i == len(pages)-1) {
+			break
+		}
+	}
+
+	return args.Error(1)
+}
+
+func (m *MockS3Client) PutObjectWithContext(
+	_ aws.Context,
+	input *s3.PutObjectInput,
+	_ ...request.Option,
+) (*s3.PutObjectOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.PutObjectOutput), args.Error(1)
+}
+
+func (m *MockS3Client) GetObjectWithContext(
+	_ aws.Context,
+	input *s3.GetObjectInput,
+	_ ...request.Option,
+) (*s3.GetObjectOutput, error) {
+	args := m.Called(input)
+	out, _ := args.Get(0).(*s3.GetObjectOutput)
+	return out, args.Error(1)
+}
+
+func (m *MockS3Client) HeadObjectWithContext(
+	_ aws.Context,
+	input *s3.HeadObjectInput,
+	_ ...request.Option,
+) (*s3.HeadObjectOutput, error) {
+	args := m.Called(input)
+	out, _ := args.Get(0).(*s3.HeadObjectOutput)
+	return out, args.Error(1)
+}
+
+func (m *MockS3Client) DeleteObjectWithContext(
+	_ aws.Context,
+	input *s3.DeleteObjectInput,
+	_ ...request.Option,
+) (*s3.DeleteObjectOutput, error) {
+	args := m.Called(input)
+	out, _ := args.Get(0).(*s3.DeleteObjectOutput)
+	return out, args.Error(1)
+}
+
+func TestS3BackendRead(t *testing.T) {
+	// Arrange the test
+	mockSvc := new(MockS3Client)
+	backend := S3Backend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			Recursive:    true,
+			Source:       "s3://test-bucket",
+			NoIndexFiles: []string{".noindex"},
+		},
+	}
+
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.Anything).Return(&s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			{
+				Key:          aws.String("prefix/file1.txt"),
+				Size:         aws.Int64(1024),
+				LastModified: aws.Time(time.Now()),
+			},
+			{
+				Key:          aws.String("prefix/file2.txt"),
+				Size:         aws.Int64(2048),
+				LastModified: aws.Time(time.Now()),
+			},
+			{
+				Key:          aws.String("prefix/smallfile1.txt"),
+				Size:         aws.Int64(4),
+				LastModified: aws.Time(time.Now()),
+			},
+			{
+				Key:          aws.String("prefix/dir1/dir1file1.txt"),
+				Size:         aws.Int64(2048),
+				LastModified: aws.Time(time.Now()),
+			},
+		},
+		CommonPrefixes: []*s3.CommonPrefix{
+			{
+				Prefix: aws.String("prefix/"),
+			},
+			{
+				Prefix: aws.String("prefix/dir1/"),
+			},
+		},
+	}, nil)
+
+	// Test reading root directory
+	items, hasNoIndex, err := backend.Read(t.Context(), "/")
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+	require.NotEmpty(t, items)
+
+	// Test reading prefix directory
+	items1, hasNoIndex, err := backend.Read(t.Context(), "prefix/")
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+	items = append(items, items1...)
+
+	// Test reading subdirectory
+	items2, hasNoIndex, err := backend.Read(t.Context(), "prefix/dir1/")
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+	items = append(items, items2...)
+
+	require.Len(t, items, 6, "There should be 6 items")
+
+	// Assert the expected items content
+	assert.Contains(t, []string{
+		"prefix/",
+		"file1.txt",
+		"file2.txt",
+		"smallfile1.txt",
+		"dir1/",
+	}, items[0].Name)
+
+	// Assert the expected prefixes are "directories"
+	for _, item := range items {
+		if item.Name == "prefix/" || item.Name == "" || item.Name == "dir1/" || item.Name == "prefix/dir1/" {
+			assert.True(t, item.IsDir)
+		} else {
+			assert.False(t, item.IsDir, "Item %s should not be a directory", item.Name)
+		}
+	}
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestS3BackendReadWithNoIndex(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	backend := S3Backend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			Recursive:    true,
+			Source:       "s3://test-bucket",
+			NoIndexFiles: []string{".noindex"},
+		},
+	}
+
+	// Mock response with a noindex file
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+		return *input.Bucket == "test-bucket" && *input.Prefix == "prefix/"
+	})).Return(&s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			{
+				Key:          aws.String("prefix/.noindex"),
+				Size:         aws.Int64(0),
+				LastModified: aws.Time(time.Now()),
+			},
+			{
+				Key:          aws.String("prefix/file1.txt"),
+				Size:         aws.Int64(1024),
+				LastModified: aws.Time(time.Now()),
+			},
+		},
+	}, nil)
+
+	// Test reading directory with noindex file
+	t.Logf("NoIndexFiles: %v", backend.cfg.NoIndexFiles)
+	items, hasNoIndex, err := backend.Read(t.Context(), "prefix/")
+	require.NoError(t, err)
+	t.Logf("hasNoIndex: %v, items: %v", hasNoIndex, items)
+	assert.True(t, hasNoIndex)
+	assert.Empty(t, items)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestS3BackendReadWithNoIndexSimple(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	backend := S3Backend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			NoIndexFiles: []string{".noindex"},
+		},
+	}
+
+	// Mock response with a noindex file
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+		return *input.Bucket == "test-bucket" && (*input.Prefix == "" || *input.Prefix == "/")
+	})).Return(&s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			{
+				Key:          aws.String(".noindex"),
+				Size:         aws.Int64(0),
+				LastModified: aws.Time(time.Now()),
+			},
+		},
+	}, nil)
+
+	// Test reading directory with noindex file
+	items, hasNoIndex, err := backend.Read(t.Context(), "")
+	require.NoError(t, err)
+	t.Logf("items: %v, hasNoIndex: %v", items, hasNoIndex)
+	assert.True(t, hasNoIndex)
+	assert.Empty(t, items)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestS3BackendReadPaginated(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	backend := S3Backend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			IndexFile:    "index.html",
+			NoIndexFiles: []string{".noindex"},
+		},
+	}
+
+	// The listing for "prefix/" is split across three pages, with objects
+	// and common prefixes spread across them.
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+		return *input.Prefix == "prefix/"
+	})).Return([]*s3.ListObjectsV2Output{
+		{
+			Contents: []*s3.Object{
+				{Key: aws.String("prefix/file1.txt"), Size: aws.Int64(1), LastModified: aws.Time(time.Now())},
+				{Key: aws.String("prefix/file2.txt"), Size: aws.Int64(2), LastModified: aws.Time(time.Now())},
+			},
+			IsTruncated:           aws.Bool(true),
+			NextContinuationToken: aws.String("token-1"),
+		},
+		{
+			Contents: []*s3.Object{
+				{Key: aws.String("prefix/file3.txt"), Size: aws.Int64(3), LastModified: aws.Time(time.Now())},
+			},
+			CommonPrefixes: []*s3.CommonPrefix{
+				{Prefix: aws.String("prefix/dir1/")},
+			},
+			IsTruncated:           aws.Bool(true),
+			NextContinuationToken: aws.String("token-2"),
+		},
+		{
+			CommonPrefixes: []*s3.CommonPrefix{
+				{Prefix: aws.String("prefix/dir2/")},
+			},
+			IsTruncated: aws.Bool(false),
+		},
+	}, nil)
+
+	// The noindex check for dir2 spans two pages, with the marker on the last.
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+		return *input.Prefix == "prefix/dir1/"
+	})).Return(&s3.ListObjectsV2Output{}, nil)
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+		return *input.Prefix == "prefix/dir2/"
+	})).Return([]*s3.ListObjectsV2Output{
+		{
+			Contents: []*s3.Object{
+				{Key: aws.String("prefix/dir2/file.txt"), Size: aws.Int64(1), LastModified: aws.Time(time.Now())},
+			},
+			IsTruncated: aws.Bool(true),
+		},
+		{
+			Contents: []*s3.Object{
+				{Key: aws.String("prefix/dir2/.noindex"), Size: aws.Int64(0), LastModified: aws.Time(time.Now())},
+			},
+		},
+	}, nil)
+
+	items, hasNoIndex, err := backend.Read(t.Context(), "prefix/")
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		if !item.NoIndex {
+			names = append(names, item.Name)
+		}
+	}
+	assert.Equal(t, []string{"file1.txt", "file2.txt", "file3.txt", "dir1/"}, names)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestS3BackendReadPaginatedNoIndexOnLaterPage(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	backend := S3Backend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			NoIndexFiles: []string{".noindex"},
+		},
+	}
+
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.Anything).Return([]*s3.ListObjectsV2Output{
+		{
+			Contents: []*s3.Object{
+				{Key: aws.String("prefix/file1.txt"), Size: aws.Int64(1), LastModified: aws.Time(time.Now())},
+			},
+			IsTruncated: aws.Bool(true),
+		},
+		{
+			Contents: []*s3.Object{
+				{Key: aws.String("prefix/.noindex"), Size: aws.Int64(0), LastModified: aws.Time(time.Now())},
+			},
+		},
+	}, nil)
+
+	items, hasNoIndex, err := backend.Read(t.Context(), "prefix/")
+	require.NoError(t, err)
+	assert.True(t, hasNoIndex)
+	assert.Empty(t, items)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestS3BackendReadSkipPatterns(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	backend := S3Backend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+		cfg: Config{
+			BasePath:  "files",
+			IndexFile: "index.html",
+			Skips:     []string{"*.tmp", "**/node_modules", "/projects/private"},
+		},
+	}
+
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+		return *input.Prefix == "files/projects/"
+	})).Return(&s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			{Key: aws.String("files/projects/notes.txt"), Size: aws.Int64(1), LastModified: aws.Time(time.Now())},
+			{Key: aws.String("files/projects/cache.tmp"), Size: aws.Int64(1), LastModified: aws.Time(time.Now())},
+		},
+		CommonPrefixes: []*s3.CommonPrefix{
+			{Prefix: aws.String("files/projects/public/")},
+			{Prefix: aws.String("files/projects/private/")},
+			{Prefix: aws.String("files/projects/node_modules/")},
+		},
+	}, nil)
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+		return *input.Prefix == "files/projects/public/"
+	})).Return(&s3.ListObjectsV2Output{}, nil)
+
+	items, _, err := backend.Read(t.Context(), "files/projects/")
+	require.NoError(t, err)
+
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	assert.Equal(t, []string{"notes.txt", "public/"}, names)
+
+	// Skipped prefixes aren't listed to look for noindex files
+	mockSvc.AssertNumberOfCalls(t, "ListObjectsV2PagesWithContext", 2)
+}
+
+func TestS3BackendReadListError(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	backend := S3Backend{
+		svc:    mockSvc,
+		bucket: "test-bucket",
+	}
+
+	mockSvc.On("ListObjectsV2PagesWithContext", mock.Anything).
+		Return([]*s3.ListObjectsV2Output{}, errors.New("access denied"))
+
+	_, _, err := backend.Read(t.Context(), "prefix/")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "access denied")
+}
+
+func TestS3BackendWrite(t *testing.T) {
+	mockSvc := new(MockS3Client)
+	s3Backend := S3Backend{
+		svc: mockSvc,
+		cfg: Config{
+			Target:    "s3://test-bucket/",
+			BasePath:  "/basepath/",
+			IndexFile: "index.html",
+		},
+	}
+
+	// Setup mock response for PutObject
+	mockSvc.On("PutObjectWithContext", mock.AnythingOfType("*s3.PutObjectInput")).Return(&s3.PutObjectOutput{}, nil)
+
+	data := Data{
+		RelativePath: "subdir/",
+	}
+	content := "<html>Test Content</html>"
+
+	// Execute the Write method
+	err := s3Backend.Write(t.Context(), data, content)
+	require.NoError(t, err)
+
+	// Verify that PutObject was called as expected
+	mockSvc.AssertCalled(t, "PutObjectWithContext", mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+		return *input.Bucket == "test-bucket" &&
+			strings.HasSuffix(*input.Key, "subdir/index.html") &&
+			*input.ContentType == "text/html" &&
+			*input.ContentEncoding == "utf-8"
+	}))
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestIsS3URI(t *testing.T) {
+	assert.True(t, isS3URI("s3://test-bucket/"))
+	assert.True(t, isS3URI("s3://test-bucket"))
+	assert.True(t, isS3URI("s3://test-bucket/one/two/three"))
+	assert.False(t, isS3URI("http://example.com/"))
+	assert.False(t, isS3URI("/mnt/foo"))
+}
+
+func TestS3URIToBucketAndPrefix(t *testing.T) {
+	bucket, prefix := uriToBucketAndPrefix("s3://test-bucket/")
+	assert.Equal(t, "test-bucket", bucket)
+	assert.Equal(t, "", prefix)
+
+	bucket, prefix = uriToBucketAndPrefix("s3://test-bucket")
+	assert.Equal(t, "test-bucket", bucket)
+	assert.Equal(t, "", prefix)
+
+	bucket, prefix = uriToBucketAndPrefix("s3://test-bucket/one/two/three")
+	assert.Equal(t, "test-bucket", bucket)
+	assert.Equal(t, "one/two/three", prefix)
+}
+
+func TestS3BackendIndexUnchanged(t *testing.T) {
+	content := "<html>Test Content</html>"
+	sha := hex.EncodeToString(sha256Sum(content))
+	md5Sum := md5.Sum([]byte(content)) // #nosec
+	etag := `"` + hex.EncodeToString(md5Sum[:]) + `"`
+
+	tests := []struct {
+		name      string
+		output    *s3.HeadObjectOutput
+		err       error
+		unchanged bool
+	}{
+		{"matching checksum metadata", &s3.HeadObjectOutput{
+			ETag:     aws.String(`"not-an-md5"`),
+			Metadata: map[string]*string{"Web-Indexer-Sha256": aws.String(sha)},
+		}, nil, true},
+		{"different checksum metadata", &s3.HeadObjectOutput{
+			ETag:     aws.String(etag),
+			Metadata: map[string]*string{"Web-Indexer-Sha256": aws.String("other")},
+		}, nil, false},
+		{"matching ETag", &s3.HeadObjectOutput{ETag: aws.String(etag)}, nil, true},
+		{"different ETag", &s3.HeadObjectOutput{ETag: aws.String(`"abc"`)}, nil, false},
+		{"missing object", nil, awserr.New("NotFound", "Not Found", nil), false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockS3Client)
+			backend := S3Backend{
+				svc: mockSvc,
+				cfg: Config{Target: "s3://test-bucket/site", BasePath: "/", IndexFile: "index.html"},
+			}
+
+			mockSvc.On("HeadObjectWithContext", mock.MatchedBy(func(input *s3.HeadObjectInput) bool {
+				return *input.Bucket == "test-bucket" && *input.Key == "site/sub/index.html"
+			})).Return(tc.output, tc.err)
+
+			unchanged, err := backend.IndexUnchanged(t.Context(), Data{RelativePath: "/sub"}, content)
+			require.NoError(t, err)
+			assert.Equal(t, tc.unchanged, unchanged)
+		})
+	}
+
+	mockSvc := new(MockS3Client)
+	backend := S3Backend{svc: mockSvc, cfg: Config{Target: "s3://test-bucket/"}}
+	mockSvc.On("HeadObjectWithContext", mock.Anything).Return(nil, errors.New("access denied"))
+	_, err := backend.IndexUnchanged(t.Context(), Data{RelativePath: "/"}, content)
+	assert.Error(t, err)
+}
@@ -0,0 +1,350 @@
+package webindexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTPBackend reads listings from and writes index files to a remote host
+// over SFTP.
+type SFTPBackend struct {
+	client *sftp.Client
+	// conn is the SSH connection the SFTP session runs on. It is nil when
+	// the client was created over another transport.
+	conn *ssh.Client
+	// posixRename is set when the server supports replacing a file with a
+	// rename, through the posix-rename@openssh.com extension.
+	posixRename bool
+	// root is the remote path given in the sftp:// URI.
+	root string
+	cfg  Config
+}
+
+var (
+	_ FileSource = &SFTPBackend{}
+	_ FileReader = &SFTPBackend{}
+	_ io.Closer  = &SFTPBackend{}
+)
+
+func (s *SFTPBackend) Read(ctx context.Context, dir string) ([]*Item, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	var items []*Item
+	log.Debugf("Listing remote files in %s", dir)
+	files, err := s.client.ReadDir(dir)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to read remote source path %s: %w", dir, err)
+	}
+
+	// First check for noindex files before processing anything else
+	noIndex, skipIndex := skipListing(s.cfg, dir, fileInfoFileNames(files))
+	if noIndex {
+		return nil, true, nil
+	}
+	if skipIndex {
+		return []*Item{}, false, nil
+	}
+
+	// Process all other files
+	for _, file := range files {
+		if shouldSkip(file.Name(), itemPath(s.cfg, dir, file.Name()), s.cfg.IndexFile, s.cfg.Skips) {
+			continue
+		}
+
+		fullPath := path.Join(dir, file.Name())
+		stat, err := s.client.Stat(fullPath)
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to stat remote file %s: %w", file.Name(), err)
+		}
+
+		// If it's a directory, check if it contains a noindex file before adding it
+		excluded := false
+		if stat.IsDir() {
+			subFiles, err := s.client.ReadDir(fullPath)
+			if err != nil {
+				return nil, false, fmt.Errorf("unable to read remote directory %s: %w", fullPath, err)
+			}
+
+			excluded = excludedDir(s.cfg, fullPath, fileInfoFileNames(subFiles))
+		}
+
+		items = append(items, &Item{
+			Name:         file.Name(),
+			Size:         stat.Size(),
+			LastModified: stat.ModTime(),
+			IsDir:        stat.IsDir(),
+			HasMetadata:  true,
+			NoIndex:      excluded,
+		})
+	}
+
+	return items, false, nil
+}
+
+// ReadFile reads a file from a remote directory.
+func (s *SFTPBackend) ReadFile(ctx context.Context, dir, name string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	file, err := s.client.Open(path.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+func (s *SFTPBackend) EnsureDirExists(ctx context.Context, relativePath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	remotePath := path.Join(s.root, relativePath)
+	if err := s.client.MkdirAll(remotePath); err != nil {
+		return fmt.Errorf("failed to ensure remote directory exists %s: %w", remotePath, err)
+	}
+	log.Debugf("Ensured remote directory exists: %s", remotePath)
+	return nil
+}
+
+// Write uploads the index file to a temporary file and renames it into
+// place, so an interrupted upload never leaves a partial index behind.
+func (s *SFTPBackend) Write(ctx context.Context, data Data, content string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	remoteDir := path.Join(s.root, data.RelativePath)
+	if err := s.client.MkdirAll(remoteDir); err != nil {
+		return fmt.Errorf("failed to create remote directory %s: %w", remoteDir, err)
+	}
+
+	filePath := path.Join(remoteDir, s.cfg.IndexFile)
+	tmpPath := path.Join(remoteDir, "."+s.cfg.IndexFile+".tmp")
+	if err := s.upload(ctx, tmpPath, content); err != nil {
+		_ = s.client.Remove(tmpPath)
+		return err
+	}
+
+	if err := s.replace(tmpPath, filePath); err != nil {
+		_ = s.client.Remove(tmpPath)
+		return fmt.Errorf("failed to rename %s to %s: %w", tmpPath, filePath, err)
+	}
+
+	log.Infof("Uploaded %s", filePath)
+	return nil
+}
+
+// replace renames the uploaded file over the existing one. Plain SFTP renames
+// fail when the new path exists, so without the posix-rename extension the
+// existing file is removed first, which briefly leaves no index in place.
+func (s *SFTPBackend) replace(tmpPath, filePath string) error {
+	if s.posixRename {
+		return s.client.PosixRename(tmpPath, filePath)
+	}
+
+	if err := s.client.Remove(filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return s.client.Rename(tmpPath, filePath)
+}
+
+// Close ends the SFTP session and closes the SSH connection.
+func (s *SFTPBackend) Close() error {
+	err := s.client.Close()
+	if s.conn != nil {
+		err = errors.Join(err, s.conn.Close())
+	}
+	return err
+}
+
+func (s *SFTPBackend) upload(ctx context.Context, remotePath, content string) error {
+	file, err := s.client.Create(remotePath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write([]byte(content)); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// fileInfoFileNames returns the names of the files, but not the directories,
+// in a remote listing.
+func fileInfoFileNames(files []os.FileInfo) []string {
+	var names []string
+	for _, file := range files {
+		if !file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+	return names
+}
+
+func isSFTPURI(uri string) bool {
+	return strings.HasPrefix(uri, "sftp://")
+}
+
+// sftpURIPath returns the remote path of an sftp:// URI.
+func sftpURIPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid SFTP URI %s: %w", uri, err)
+	}
+
+	if u.Path == "" {
+		return "/", nil
+	}
+
+	return path.Clean(u.Path), nil
+}
+
+// newSFTPBackend connects to the host in the given sftp://user@host:port/path
+// URI. Keys are offered from the SSH agent and the configured key file, and
+// the host key is verified against the known_hosts file.
+func newSFTPBackend(uri string, cfg Config) (*SFTPBackend, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SFTP URI %s: %w", uri, err)
+	}
+
+	root, err := sftpURIPath(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	username := u.User.Username()
+	if username == "" {
+		current, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("unable to determine SFTP user: %w", err)
+		}
+		username = current.Username
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "22")
+	}
+
+	agentSigners, agentConn := sshAgentSigners()
+	if agentConn != nil {
+		// The agent is only needed to sign the authentication request
+		defer agentConn.Close()
+	}
+
+	sshConfig, err := sftpClientConfig(username, cfg, agentSigners)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debugf("Connecting to %s@%s over SFTP", username, addr)
+	conn, err := ssh.Dial("tcp", addr, sshConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start SFTP session on %s: %w", addr, err)
+	}
+
+	_, posixRename := client.HasExtension("posix-rename@openssh.com")
+	if !posixRename {
+		log.Debugf("%s doesn't support posix-rename, index files are removed before they're replaced", addr)
+	}
+
+	return &SFTPBackend{client: client, conn: conn, posixRename: posixRename, root: root, cfg: cfg}, nil
+}
+
+// sshAgentSigners returns the keys of the running SSH agent, if any. They sign
+// through the returned connection to the agent, which the caller closes once
+// connected. It is nil when no agent is used.
+func sshAgentSigners() ([]ssh.Signer, net.Conn) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, nil
+	}
+
+	agentConn, err := net.Dial("unix", sock)
+	if err != nil {
+		log.Warnf("Unable to connect to SSH agent: %v", err)
+		return nil, nil
+	}
+
+	signers, err := agent.NewClient(agentConn).Signers()
+	if err != nil {
+		log.Warnf("Unable to list SSH agent keys: %v", err)
+	}
+
+	return signers, agentConn
+}
+
+// sftpClientConfig builds the SSH client configuration for the given user,
+// authenticating with the signers and the configured key file.
+func sftpClientConfig(username string, cfg Config, signers []ssh.Signer) (*ssh.ClientConfig, error) {
+	if cfg.SFTPKeyFile != "" {
+		key, err := os.ReadFile(cfg.SFTPKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read SSH key file %s: %w", cfg.SFTPKeyFile, err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse SSH key file %s: %w", cfg.SFTPKeyFile, err)
+		}
+		signers = append(signers, signer)
+	}
+
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("no SSH keys available: start an SSH agent or set sftp_key_file")
+	}
+
+	knownHostsFile := cfg.SFTPKnownHosts
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("unable to locate known_hosts: %w", err)
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load known hosts from %s: %w", knownHostsFile, err)
+	}
+
+	return &ssh.ClientConfig{
+		User:            username,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback: hostKeyCallback,
+	}, nil
+}
@@ -0,0 +1,300 @@
+package webindexer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// newPipeSFTPClient returns an SFTP client connected to an in-process SFTP
+// server over a pipe.
+func newPipeSFTPClient(t *testing.T) *sftp.Client {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+	server, err := sftp.NewServer(serverConn)
+	require.NoError(t, err)
+	go func() { _ = server.Serve() }()
+
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	return client
+}
+
+func TestSFTPBackendRead(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "file1.txt"), []byte("content"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "index.html"), []byte("old"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, "dir1"), 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, "private"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "private", ".noindex"), nil, 0o644))
+
+	backend := SFTPBackend{
+		client: newPipeSFTPClient(t),
+		root:   tempDir,
+		cfg: Config{
+			IndexFile:    "index.html",
+			NoIndexFiles: []string{".noindex"},
+		},
+	}
+
+	items, hasNoIndex, err := backend.Read(t.Context(), tempDir)
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+
+	found := map[string]bool{}
+	for _, item := range items {
+		found[item.Name] = item.NoIndex
+		assert.True(t, item.HasMetadata)
+	}
+	assert.Equal(t, map[string]bool{"file1.txt": false, "dir1": false, "private": true}, found)
+}
+
+func TestSFTPBackendReadWithNoIndexAndSkipIndex(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, "noindex"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "noindex", ".noindex"), nil, 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, "skipindex"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "skipindex", ".skipindex"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "skipindex", "file.txt"), nil, 0o644))
+
+	backend := SFTPBackend{
+		client: newPipeSFTPClient(t),
+		root:   tempDir,
+		cfg: Config{
+			NoIndexFiles:   []string{".noindex"},
+			SkipIndexFiles: []string{".skipindex"},
+		},
+	}
+
+	items, hasNoIndex, err := backend.Read(t.Context(), filepath.Join(tempDir, "noindex"))
+	require.NoError(t, err)
+	assert.True(t, hasNoIndex)
+	assert.Empty(t, items)
+
+	items, hasNoIndex, err = backend.Read(t.Context(), filepath.Join(tempDir, "skipindex"))
+	require.NoError(t, err)
+	assert.False(t, hasNoIndex)
+	assert.Empty(t, items)
+}
+
+func TestSFTPBackendWrite(t *testing.T) {
+	for _, posixRename := range []bool{true, false} {
+		t.Run(fmt.Sprintf("posixRename=%v", posixRename), func(t *testing.T) {
+			tempDir := t.TempDir()
+
+			backend := SFTPBackend{
+				client:      newPipeSFTPClient(t),
+				posixRename: posixRename,
+				root:        tempDir,
+				cfg: Config{
+					IndexFile: "index.html",
+				},
+			}
+
+			require.NoError(t, backend.EnsureDirExists(t.Context(), "/sub/dir"))
+			assert.DirExists(t, filepath.Join(tempDir, "sub", "dir"))
+
+			require.NoError(t, backend.Write(t.Context(), Data{RelativePath: "/"}, "old"))
+			require.NoError(t, backend.Write(t.Context(), Data{RelativePath: "/"}, "root"))
+			require.NoError(t, backend.Write(t.Context(), Data{RelativePath: "/sub/dir"}, "nested"))
+
+			content, err := os.ReadFile(filepath.Join(tempDir, "index.html"))
+			require.NoError(t, err)
+			assert.Equal(t, "root", string(content))
+
+			content, err = os.ReadFile(filepath.Join(tempDir, "sub", "dir", "index.html"))
+			require.NoError(t, err)
+			assert.Equal(t, "nested", string(content))
+
+			assert.NoFileExists(t, filepath.Join(tempDir, ".index.html.tmp"))
+		})
+	}
+}
+
+func TestSFTPBackendWriteCanceled(t *testing.T) {
+	tempDir := t.TempDir()
+
+	backend := SFTPBackend{
+		client: newPipeSFTPClient(t),
+		root:   tempDir,
+		cfg: Config{
+			IndexFile: "index.html",
+		},
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	require.ErrorIs(t, backend.Write(ctx, Data{RelativePath: "/"}, "root"), context.Canceled)
+
+	entries, err := os.ReadDir(tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSFTPURIPath(t *testing.T) {
+	p, err := sftpURIPath("sftp://user@host/srv/files/")
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/files", p)
+
+	p, err = sftpURIPath("sftp://host:2222")
+	require.NoError(t, err)
+	assert.Equal(t, "/", p)
+
+	assert.True(t, isSFTPURI("sftp://host/path"))
+	assert.False(t, isSFTPURI("s3://bucket/path"))
+}
+
+// startSSHServer starts an SSH server on a local port that serves the SFTP
+// subsystem to clients authenticating with the given key. It returns the
+// listening address and the server's host key.
+func startSSHServer(t *testing.T, authorized ssh.PublicKey) (string, ssh.PublicKey) {
+	t.Helper()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(authorized.Marshal()) {
+				return &ssh.Permissions{}, nil
+			}
+			return nil, assert.AnError
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSHConn(conn, config)
+		}
+	}()
+
+	return listener.Addr().String(), hostSigner.PublicKey()
+}
+
+func serveSSHConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+				if !ok {
+					continue
+				}
+
+				server, err := sftp.NewServer(channel)
+				if err != nil {
+					return
+				}
+				_ = server.Serve()
+				server.Close()
+			}
+		}()
+	}
+}
+
+func TestNewSFTPBackend(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	clientPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+
+	addr, hostKey := startSSHServer(t, clientPub)
+
+	tempDir := t.TempDir()
+	keyBlock, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+	keyFile := filepath.Join(tempDir, "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(keyBlock), 0o600))
+
+	knownHosts := filepath.Join(tempDir, "known_hosts")
+	require.NoError(t, os.WriteFile(knownHosts, []byte(knownhosts.Line([]string{addr}, hostKey)+"\n"), 0o600))
+
+	remoteDir := filepath.Join(tempDir, "remote")
+	require.NoError(t, os.Mkdir(remoteDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "file1.txt"), []byte("content"), 0o644))
+
+	cfg := Config{
+		IndexFile:      "index.html",
+		SFTPKeyFile:    keyFile,
+		SFTPKnownHosts: knownHosts,
+	}
+
+	backend, err := newSFTPBackend("sftp://tester@"+addr+remoteDir, cfg)
+	require.NoError(t, err)
+	defer backend.Close()
+	assert.Equal(t, remoteDir, backend.root)
+	assert.True(t, backend.posixRename)
+
+	items, _, err := backend.Read(t.Context(), remoteDir)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "file1.txt", items[0].Name)
+
+	require.NoError(t, backend.Write(t.Context(), Data{RelativePath: "/"}, "<html></html>"))
+	assert.FileExists(t, filepath.Join(remoteDir, "index.html"))
+
+	// An unknown host key must be rejected
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ssh.NewPublicKey(otherPub)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(knownHosts, []byte(knownhosts.Line([]string{addr}, otherKey)+"\n"), 0o600))
+
+	_, err = newSFTPBackend("sftp://tester@"+addr+remoteDir, cfg)
+	assert.Error(t, err)
+}
+
+func TestSFTPClientConfigRequiresKeys(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	_, err := sftpClientConfig("tester", Config{}, nil)
+	assert.ErrorContains(t, err, "no SSH keys available")
+}
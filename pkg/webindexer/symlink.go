@@ -0,0 +1,140 @@
+package webindexer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+type SymlinkPolicy string
+
+const (
+	// SymlinksFollow lists symlinks as the file or directory they point to and
+	// indexes symlinked directories.
+	SymlinksFollow SymlinkPolicy = "follow"
+	// SymlinksSkip leaves symlinks out of listings.
+	SymlinksSkip SymlinkPolicy = "skip"
+	// SymlinksShowAsLink lists symlinks as links without following them.
+	SymlinksShowAsLink SymlinkPolicy = "show-as-link"
+)
+
+func (c Config) SymlinksValue() SymlinkPolicy {
+	switch c.Symlinks {
+	case "", "follow":
+		return SymlinksFollow
+	case "skip":
+		return SymlinksSkip
+	case "show-as-link":
+		return SymlinksShowAsLink
+	default:
+		return ""
+	}
+}
+
+// localEntry is an entry of a local directory resolved according to the
+// symlink policy.
+type localEntry struct {
+	info       fs.FileInfo
+	isSymlink  bool
+	linkTarget string
+}
+
+// resolveEntry returns the file info of an entry of dir. It returns false if
+// the entry is left out of the listing, such as a symlink that is skipped, is
+// broken, loops back to one of its parents or points outside of the source.
+func (l *LocalBackend) resolveEntry(dir string, file fs.DirEntry) (localEntry, bool, error) {
+	fullPath := filepath.Join(dir, file.Name())
+	if file.Type()&fs.ModeSymlink == 0 {
+		info, err := os.Stat(fullPath)
+		if err != nil {
+			return localEntry{}, false, fmt.Errorf("unable to stat file %s: %w", file.Name(), err)
+		}
+		return localEntry{info: info}, true, nil
+	}
+
+	policy := l.cfg.SymlinksValue()
+	if policy == SymlinksSkip {
+		log.Debugf("Skipping symlink %s", fullPath)
+		return localEntry{}, false, nil
+	}
+
+	target, err := os.Readlink(fullPath)
+	if err != nil {
+		return localEntry{}, false, fmt.Errorf("unable to read symlink %s: %w", fullPath, err)
+	}
+
+	if policy == SymlinksShowAsLink {
+		info, err := os.Lstat(fullPath)
+		if err != nil {
+			return localEntry{}, false, fmt.Errorf("unable to stat file %s: %w", file.Name(), err)
+		}
+		return localEntry{info: info, isSymlink: true, linkTarget: target}, true, nil
+	}
+
+	info, err := os.Stat(fullPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Warnf("Skipping %s (symlink target %s doesn't exist)", fullPath, target)
+		return localEntry{}, false, nil
+	}
+	if err != nil {
+		return localEntry{}, false, fmt.Errorf("unable to stat file %s: %w", file.Name(), err)
+	}
+
+	if l.cfg.SymlinksInRoot {
+		inside, err := l.withinRoot(fullPath)
+		if err != nil {
+			return localEntry{}, false, err
+		}
+		if !inside {
+			log.Warnf("Skipping %s (symlink target %s is outside the source)", fullPath, target)
+			return localEntry{}, false, nil
+		}
+	}
+
+	if info.IsDir() && l.isParent(dir, info) {
+		log.Warnf("Skipping %s (symlink loops back to a parent directory)", fullPath)
+		return localEntry{}, false, nil
+	}
+
+	return localEntry{info: info, isSymlink: true, linkTarget: target}, true, nil
+}
+
+// isParent reports whether a directory is the same as dir or any of its parents
+// up to the root of the source, comparing device and inode numbers. Following
+// a symlink to such a directory would recurse forever.
+func (l *LocalBackend) isParent(dir string, info fs.FileInfo) bool {
+	root := filepath.Clean(l.cfg.BasePath)
+	for p := filepath.Clean(dir); ; p = filepath.Dir(p) {
+		if parent, err := os.Stat(p); err == nil && os.SameFile(parent, info) {
+			return true
+		}
+		if p == root || p == filepath.Dir(p) {
+			return false
+		}
+	}
+}
+
+// withinRoot reports whether a symlink resolves to a path inside the source.
+func (l *LocalBackend) withinRoot(fullPath string) (bool, error) {
+	root, err := filepath.EvalSymlinks(l.cfg.BasePath)
+	if err != nil {
+		return false, fmt.Errorf("unable to resolve source path %s: %w", l.cfg.BasePath, err)
+	}
+
+	target, err := filepath.EvalSymlinks(fullPath)
+	if err != nil {
+		return false, fmt.Errorf("unable to resolve symlink %s: %w", fullPath, err)
+	}
+
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return false, nil
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
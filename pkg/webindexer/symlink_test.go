@@ -0,0 +1,128 @@
+package webindexer
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSymlinkSource creates a source with a symlink to a file, a symlink loop
+// back to the root, a symlink to a directory outside of the source and a
+// broken symlink.
+func newSymlinkSource(t *testing.T) string {
+	t.Helper()
+
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644))
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "docs"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "docs", "guide.txt"), []byte("guide"), 0o644))
+	require.NoError(t, os.Symlink(filepath.Join("docs", "guide.txt"), filepath.Join(sourceDir, "latest.txt")))
+	require.NoError(t, os.Symlink("..", filepath.Join(sourceDir, "docs", "up")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(sourceDir, "outside")))
+	require.NoError(t, os.Symlink("missing.txt", filepath.Join(sourceDir, "broken")))
+
+	return sourceDir
+}
+
+func readSymlinkSource(t *testing.T, cfg Config, dir string) map[string]*Item {
+	t.Helper()
+
+	cfg.IndexFile = "index.html"
+	backend := &LocalBackend{path: cfg.BasePath, cfg: cfg}
+	items, _, err := backend.Read(t.Context(), filepath.Join(cfg.BasePath, dir))
+	require.NoError(t, err)
+
+	byName := map[string]*Item{}
+	for _, item := range items {
+		byName[item.Name] = item
+	}
+	return byName
+}
+
+func TestLocalBackendReadSymlinks(t *testing.T) {
+	sourceDir := newSymlinkSource(t)
+
+	t.Run("follow", func(t *testing.T) {
+		items := readSymlinkSource(t, Config{BasePath: sourceDir}, "")
+		require.Contains(t, items, "latest.txt")
+		assert.True(t, items["latest.txt"].IsSymlink)
+		assert.Equal(t, filepath.Join("docs", "guide.txt"), items["latest.txt"].LinkTarget)
+		assert.Equal(t, int64(len("guide")), items["latest.txt"].Size)
+		require.Contains(t, items, "outside")
+		assert.True(t, items["outside"].IsDir)
+		assert.False(t, items["docs"].IsSymlink)
+
+		// The symlink back to the root and the broken symlink are skipped
+		assert.NotContains(t, readSymlinkSource(t, Config{BasePath: sourceDir}, "docs"), "up")
+		assert.NotContains(t, items, "broken")
+	})
+
+	t.Run("follow within root", func(t *testing.T) {
+		items := readSymlinkSource(t, Config{BasePath: sourceDir, SymlinksInRoot: true}, "")
+		assert.Contains(t, items, "latest.txt")
+		assert.NotContains(t, items, "outside")
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		items := readSymlinkSource(t, Config{BasePath: sourceDir, Symlinks: "skip"}, "")
+		assert.ElementsMatch(t, []string{"docs"}, itemKeys(items))
+	})
+
+	t.Run("show as link", func(t *testing.T) {
+		items := readSymlinkSource(t, Config{BasePath: sourceDir, Symlinks: "show-as-link"}, "")
+		require.Contains(t, items, "outside")
+		assert.True(t, items["outside"].IsSymlink)
+		assert.False(t, items["outside"].IsDir)
+		require.Contains(t, items, "broken")
+		assert.Equal(t, "missing.txt", items["broken"].LinkTarget)
+
+		// Symlinks to directories aren't followed, so there's nothing to loop
+		items = readSymlinkSource(t, Config{BasePath: sourceDir, Symlinks: "show-as-link"}, "docs")
+		require.Contains(t, items, "up")
+		assert.Equal(t, "..", items["up"].LinkTarget)
+	})
+}
+
+func TestGenerate_SymlinkLoop(t *testing.T) {
+	sourceDir := newSymlinkSource(t)
+	targetDir := t.TempDir()
+
+	indexer, err := New(Config{
+		Source:         sourceDir,
+		Target:         targetDir,
+		Recursive:      true,
+		SymlinksInRoot: true,
+		IndexFile:      "index.html",
+		SortBy:         "name",
+		Order:          "asc",
+		DateFormat:     "2006-01-02 15:04:05 MST",
+		Timeout:        10 * time.Second,
+	})
+	require.NoError(t, err)
+
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+
+	tree := readTree(t, targetDir)
+	assert.Equal(t, []string{"docs/index.html", "index.html"}, slices.Sorted(maps.Keys(tree)))
+	assert.Contains(t, tree["index.html"], `class="symlink"`)
+	assert.Contains(t, tree["index.html"], "🔗")
+	// The target of a symlink isn't published
+	assert.NotContains(t, tree["index.html"], "docs/guide.txt")
+}
+
+func itemKeys(items map[string]*Item) []string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,167 @@
+package webindexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// entryTree is an in-memory directory tree for read-only sources that are
+// loaded up front, such as archives and git trees.
+type entryTree struct {
+	// dirs maps each directory, as an absolute slash separated path, to its
+	// entries keyed by name.
+	dirs map[string]map[string]*treeEntry
+}
+
+type treeEntry struct {
+	name         string
+	isDir        bool
+	size         int64
+	lastModified time.Time
+	// hasMetadata is false for directories that are only implied by the
+	// paths of their contents and have no entry of their own.
+	hasMetadata bool
+	// content holds the data of the files the indexer reads from sources,
+	// which are kept in memory when the tree is loaded.
+	content []byte
+}
+
+func newEntryTree() *entryTree {
+	return &entryTree{dirs: map[string]map[string]*treeEntry{"/": {}}}
+}
+
+// add records an entry, creating any parent directories that don't have
+// entries of their own.
+func (t *entryTree) add(name string, isDir bool, size int64, modTime time.Time) {
+	p := path.Clean("/" + name)
+	if p == "/" {
+		return
+	}
+
+	dir, base := path.Split(p)
+	dir = path.Clean(dir)
+	t.ensureDir(dir)
+
+	entry, ok := t.dirs[dir][base]
+	if !ok {
+		entry = &treeEntry{name: base}
+		t.dirs[dir][base] = entry
+	}
+	entry.isDir = isDir
+	entry.size = size
+	entry.lastModified = modTime
+	entry.hasMetadata = true
+
+	if isDir {
+		t.ensureDir(p)
+	}
+}
+
+// ensureDir makes sure the directory and its parents exist in the tree.
+func (t *entryTree) ensureDir(dir string) {
+	if _, ok := t.dirs[dir]; ok {
+		return
+	}
+	t.dirs[dir] = map[string]*treeEntry{}
+
+	parent, base := path.Split(dir)
+	parent = path.Clean(parent)
+	t.ensureDir(parent)
+	if _, ok := t.dirs[parent][base]; !ok {
+		t.dirs[parent][base] = &treeEntry{name: base, isDir: true}
+	}
+}
+
+// read lists the directory the same way the other backends do, honoring
+// noindex and skipindex files and the configured skips. The source is used
+// in log and error messages.
+func (t *entryTree) read(ctx context.Context, dir string, cfg Config, source string) ([]*Item, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	dir = path.Clean("/" + dir)
+	log.Debugf("Listing %s in %s", dir, source)
+
+	entries, ok := t.dirs[dir]
+	if !ok {
+		return nil, false, fmt.Errorf("unable to read %s: not a directory in %s", dir, source)
+	}
+
+	// First check for noindex files before processing anything else
+	noIndex, skipIndex := skipListing(cfg, dir, t.fileNames(dir))
+	if noIndex {
+		return nil, true, nil
+	}
+	if skipIndex {
+		return []*Item{}, false, nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var items []*Item
+	for _, name := range names {
+		entry := entries[name]
+		if shouldSkip(name, itemPath(cfg, dir, name), cfg.IndexFile, cfg.Skips) {
+			continue
+		}
+
+		items = append(items, &Item{
+			Name:         name,
+			Size:         entry.size,
+			LastModified: entry.lastModified.Local(),
+			IsDir:        entry.isDir,
+			HasMetadata:  entry.hasMetadata,
+			NoIndex:      entry.isDir && excludedDir(cfg, path.Join(dir, name), t.fileNames(path.Join(dir, name))),
+		})
+	}
+
+	return items, false, nil
+}
+
+// setContent keeps the content of a file that was added to the tree.
+func (t *entryTree) setContent(name string, content []byte) {
+	dir, base := path.Split(path.Clean("/" + name))
+	if entry, ok := t.dirs[path.Clean(dir)][base]; ok {
+		entry.content = content
+	}
+}
+
+// readFile returns the content of the named file in dir. Only the files the
+// indexer reads from sources are kept, see sourceFile. The source is used in
+// error messages.
+func (t *entryTree) readFile(dir, name, source string) ([]byte, error) {
+	p := path.Join("/", dir, name)
+	entry, ok := t.dirs[path.Clean("/"+dir)][name]
+	if !ok || entry.isDir {
+		return nil, fmt.Errorf("%s in %s: %w", p, source, fs.ErrNotExist)
+	}
+	if entry.content == nil && entry.size > 0 {
+		return nil, fmt.Errorf("reading %s in %s is %w", p, source, errors.ErrUnsupported)
+	}
+
+	return entry.content, nil
+}
+
+// fileNames returns the names of the files, but not the directories, in dir.
+func (t *entryTree) fileNames(dir string) []string {
+	var names []string
+	for name, entry := range t.dirs[dir] {
+		if !entry.isDir {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,1117 @@
+// Package webindexer generates index files for a directory, bucket or any
+// other FileSource.
+//
+// Most callers create an Indexer with New, which sets up the source and target
+// from the URIs in the Config. NewWithSource accepts any FileSource instead,
+// such as one created from an fs.FS with NewFSSource:
+//
+//	src := webindexer.NewFSSource(content, cfg)
+//	indexer, err := webindexer.NewWithSource(cfg, src)
+//	if err != nil {
+//		return err
+//	}
+//	result, err := indexer.Generate(ctx, nil, indexer.Cfg.BasePath)
+package webindexer
+
+import (
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/charmbracelet/log"
+)
+
+//go:embed templates/themes/default.html.tmpl
+var defaultTemplate string
+
+//go:embed templates/themes/solarized.html.tmpl
+var solarizedTemplate string
+
+//go:embed templates/themes/nord.html.tmpl
+var nordTemplate string
+
+//go:embed templates/themes/dracula.html.tmpl
+var draculaTemplate string
+
+// Indexer is the main struct for the webindexer package.
+type Indexer struct {
+	Cfg          Config
+	Source       FileSource
+	Target       FileSource
+	s3           *s3.S3
+	gcs          GCSAPI
+	azure        AzureAPI
+	BackendSetup BackendSetup
+	// workers limits how many directories are generated at once. It is nil
+	// when directories are generated one at a time.
+	workers chan struct{}
+	// manifest holds the directory fingerprints in incremental mode.
+	manifest *manifest
+	// indexed holds the directories given an index when pruning.
+	indexed *indexedDirs
+	// failures collects failed directories in keep-going mode.
+	failures *failures
+	stats    *runStats
+	// filters caches the ignore files and include search results.
+	filters *itemFilter
+	// closers holds the connections opened by the backends set up for the
+	// indexer, which are closed by Close.
+	closers []io.Closer
+}
+
+// FileSource is an interface for listing the contents of a directory or S3
+// bucket. Implementations should stop and return the context's error once it
+// is canceled, and must not leave a partially written index file behind.
+type FileSource interface {
+	Read(ctx context.Context, path string) ([]*Item, bool, error)
+	Write(ctx context.Context, data Data, content string) error
+	EnsureDirExists(ctx context.Context, relativePath string) error
+}
+
+// IndexComparer is implemented by targets that can tell whether an index file
+// already has the given content. Unchanged index files are not written again.
+type IndexComparer interface {
+	IndexUnchanged(ctx context.Context, data Data, content string) (bool, error)
+}
+
+// Item represents an S3 key, or a local file/directory.
+type Item struct {
+	Name         string
+	Size         int64
+	LastModified time.Time
+	URL          string
+	IsDir        bool
+	Items        []Item
+	HasMetadata  bool
+	IsSymlink    bool
+	LinkTarget   string
+	// NoIndex is set for subdirectories that have a noindex file. They are
+	// returned by Read so that they can be reported as skipped, but aren't
+	// listed.
+	NoIndex bool
+}
+
+// Data holds the template data.
+type Data struct {
+	Title              string
+	Path               string
+	RootPath           string
+	RelativePath       string
+	URL                string
+	Items              []TemplateItem
+	Parent             string
+	HasParent          bool
+	ParentURL          string
+	ParentText         string
+	ParentSize         string
+	ParentLastModified string
+}
+
+type TemplateItem struct {
+	Name         string
+	Size         string
+	LastModified string
+	URL          string
+	IsDir        bool
+	// IsSymlink is set for items that are symlinks in a local source, with
+	// LinkTarget holding the path the symlink points to.
+	IsSymlink  bool
+	LinkTarget string
+}
+
+// ErrReadOnlySource is returned when writing to a source that can only be
+// read from.
+var ErrReadOnlySource = errors.New("source is read-only")
+
+type BackendSetup interface {
+	Setup(indexer *Indexer) error
+}
+
+type defaultBackendSetup struct{}
+
+func (d defaultBackendSetup) Setup(indexer *Indexer) error {
+	return setupBackends(indexer)
+}
+
+// New creates a new Indexer, taking the initial configuration and returning a
+// updating it with the service, source and target paths.
+func New(cfg Config) (*Indexer, error) {
+	cfg = skipManifest(cfg)
+	indexer := &Indexer{
+		Cfg:          cfg,
+		BackendSetup: defaultBackendSetup{},
+		workers:      newWorkers(cfg.Concurrency),
+		filters:      &itemFilter{},
+	}
+
+	if err := indexer.Cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := indexer.BackendSetup.Setup(indexer); err != nil {
+		_ = indexer.Close()
+		return nil, err
+	}
+
+	return indexer, nil
+}
+
+// NewWithSource creates a new Indexer that reads from the given FileSource
+// instead of the configured source URI. Paths passed to the source start at
+// "/". The target is set up from the configuration as with New.
+func NewWithSource(cfg Config, source FileSource) (*Indexer, error) {
+	if cfg.Source == "" {
+		cfg.Source = "/"
+	}
+
+	cfg = skipManifest(cfg)
+	indexer := &Indexer{
+		Cfg:          cfg,
+		BackendSetup: sourceBackendSetup{source: source},
+		workers:      newWorkers(cfg.Concurrency),
+		filters:      &itemFilter{},
+	}
+
+	if err := indexer.Cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := indexer.BackendSetup.Setup(indexer); err != nil {
+		_ = indexer.Close()
+		return nil, err
+	}
+
+	return indexer, nil
+}
+
+// Close closes the connections opened for the source and target backends.
+// Sources given to NewWithSource are left to the caller to close.
+func (i Indexer) Close() error {
+	var errs []error
+	for _, c := range i.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// skipManifest adds the incremental manifest to the skipped files so that it
+// isn't listed when the source and target are the same.
+func skipManifest(cfg Config) Config {
+	if cfg.Incremental {
+		cfg.Skips = append(slices.Clone(cfg.Skips), manifestFileName(cfg))
+	}
+	return cfg
+}
+
+// newWorkers returns the worker pool for the given concurrency. The calling
+// goroutine always does some of the work, so the pool has one slot fewer
+// than the concurrency.
+func newWorkers(concurrency int) chan struct{} {
+	if concurrency <= 1 {
+		return nil
+	}
+	return make(chan struct{}, concurrency-1)
+}
+
+// sourceBackendSetup uses a FileSource provided by the caller and only sets
+// up the target.
+type sourceBackendSetup struct {
+	source FileSource
+}
+
+func (s sourceBackendSetup) Setup(indexer *Indexer) error {
+	if err := setupClients(indexer, indexer.Cfg.Target); err != nil {
+		return err
+	}
+
+	indexer.Cfg.BasePath = "/"
+	indexer.Source = s.source
+
+	return setupTarget(indexer)
+}
+
+func joinURL(baseURL string, parts ...string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+
+	joinedPath := path.Join(parts...)
+	u.Path = path.Join(u.Path, joinedPath)
+
+	return u.String(), nil
+}
+
+func resolveParentPath(baseURL, parent, indexFile string, linkToIndexes bool) string {
+	parentURL, err := joinURL(baseURL, parent)
+	if err != nil {
+		log.Error("Error joining URL:", err)
+	}
+
+	if linkToIndexes {
+		parentURL += indexFile
+	}
+	return parentURL
+}
+
+func resolveItemURL(baseURL, path, name string, isDir, linkToIndexes bool, indexFile string) string {
+	var err error
+	url := name
+	if baseURL != "" {
+		url, err = joinURL(baseURL, path, name)
+		if err != nil {
+			log.Error("Error joining URL:", err)
+		}
+	}
+
+	if isDir {
+		url = strings.TrimSuffix(url, "/") + "/"
+		if linkToIndexes {
+			url += indexFile
+		}
+	}
+	return url
+}
+
+// isRemoteURI reports whether the URI refers to anything other than a local
+// path.
+func isRemoteURI(uri string) bool {
+	return isBucketURI(uri) || isSFTPURI(uri) || isHTTPURI(uri) || isGitURI(uri)
+}
+
+// isReadOnlyURI reports whether the URI can only be used as a source.
+func isReadOnlyURI(uri string) bool {
+	return isHTTPURI(uri) || isArchivePath(uri) || isGitURI(uri)
+}
+
+// setupBackends sets up the source and target backends for the indexer.
+func setupBackends(indexer *Indexer) error {
+	var err error
+
+	if err := setupClients(indexer, indexer.Cfg.Source, indexer.Cfg.Target); err != nil {
+		return err
+	}
+
+	// For local directories, convert relative paths to absolute paths
+	if !isRemoteURI(indexer.Cfg.Source) {
+		absPath, err := filepath.Abs(indexer.Cfg.Source)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path for source: %w", err)
+		}
+		log.Debugf("Converted source path from %s to %s", indexer.Cfg.Source, absPath)
+		indexer.Cfg.Source = absPath
+	}
+
+	indexer.Cfg.BasePath = strings.TrimSuffix(indexer.Cfg.Source, "/")
+	switch {
+	case isBucketURI(indexer.Cfg.Source):
+		_, prefix := uriToBucketAndPrefix(indexer.Cfg.Source)
+		if prefix == "" {
+			indexer.Cfg.BasePath = "/"
+		} else {
+			indexer.Cfg.BasePath = prefix
+		}
+	case isSFTPURI(indexer.Cfg.Source):
+		indexer.Cfg.BasePath, err = sftpURIPath(indexer.Cfg.Source)
+		if err != nil {
+			return err
+		}
+	case isHTTPURI(indexer.Cfg.Source):
+		indexer.Cfg.BasePath, err = httpURIPath(indexer.Cfg.Source)
+		if err != nil {
+			return err
+		}
+	case isGitURI(indexer.Cfg.Source), isArchivePath(indexer.Cfg.Source):
+		// Paths inside a git tree or archive are relative to its root
+		indexer.Cfg.BasePath = "/"
+	}
+
+	if err := setupTarget(indexer); err != nil {
+		return err
+	}
+
+	indexer.Source, err = setupBackend(indexer.Cfg.Source, indexer)
+	return err
+}
+
+// setupClients creates the cloud storage clients needed by any of the URIs.
+func setupClients(indexer *Indexer, uris ...string) error {
+	anyURI := func(match func(string) bool) bool {
+		for _, uri := range uris {
+			if match(uri) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if anyURI(isS3URI) {
+		log.Debug("Setting up S3 session")
+		cfg := aws.NewConfig()
+		if indexer.Cfg.S3Endpoint != "" {
+			cfg = cfg.WithEndpoint(indexer.Cfg.S3Endpoint)
+		}
+		sess, err := session.NewSession(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create AWS session: %w", err)
+		}
+
+		indexer.s3 = s3.New(sess)
+	}
+
+	if anyURI(isGCSURI) {
+		log.Debug("Setting up GCS client")
+		client, err := newGCSClient(indexer.Cfg.GCSEndpoint)
+		if err != nil {
+			return fmt.Errorf("failed to create GCS client: %w", err)
+		}
+
+		indexer.gcs = client
+	}
+
+	if anyURI(isAzureURI) {
+		log.Debug("Setting up Azure Blob Storage client")
+		client, err := newAzureClient(indexer.Cfg.AzureAccount, indexer.Cfg.AzureEndpoint)
+		if err != nil {
+			return fmt.Errorf("failed to create Azure Blob Storage client: %w", err)
+		}
+
+		indexer.azure = client
+	}
+
+	return nil
+}
+
+// setupTarget sets up the target backend, which must be writable.
+func setupTarget(indexer *Indexer) error {
+	if isReadOnlyURI(indexer.Cfg.Target) {
+		return fmt.Errorf("%s can only be used as a source: %w", indexer.Cfg.Target, ErrReadOnlySource)
+	}
+
+	target, err := setupBackend(indexer.Cfg.Target, indexer)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := target.(ManifestStore); indexer.Cfg.Incremental && !ok {
+		return fmt.Errorf("incremental mode is not supported for target %s", indexer.Cfg.Target)
+	}
+
+	_, pruner := target.(IndexPruner)
+	_, reader := target.(IndexReader)
+	if indexer.Cfg.Prune && (!pruner || !reader) {
+		return fmt.Errorf("pruning is not supported for target %s", indexer.Cfg.Target)
+	}
+
+	if indexer.Cfg.DryRun {
+		target = newDryRunTarget(target, indexer.Cfg)
+	}
+
+	indexer.Target = target
+	return nil
+}
+
+// setupBackend sets up the backend for the given URI.
+func setupBackend(uri string, indexer *Indexer) (FileSource, error) {
+	log.Debugf("Setting up backend for %s", uri)
+	if isS3URI(uri) {
+		bucket, _ := uriToBucketAndPrefix(uri)
+		return &S3Backend{svc: indexer.s3, bucket: bucket, cfg: indexer.Cfg}, nil
+	}
+	if isGCSURI(uri) {
+		bucket, _ := uriToBucketAndPrefix(uri)
+		return &GCSBackend{svc: indexer.gcs, bucket: bucket, cfg: indexer.Cfg}, nil
+	}
+	if isAzureURI(uri) {
+		containerName, _ := uriToBucketAndPrefix(uri)
+		return &AzureBackend{svc: indexer.azure, container: containerName, cfg: indexer.Cfg}, nil
+	}
+	if isSFTPURI(uri) {
+		backend, err := newSFTPBackend(uri, indexer.Cfg)
+		if err != nil {
+			return nil, err
+		}
+		indexer.closers = append(indexer.closers, backend)
+		return backend, nil
+	}
+	if isHTTPURI(uri) {
+		return newHTTPBackend(uri, indexer.Cfg)
+	}
+	if isGitURI(uri) {
+		return newGitBackend(uri, indexer.Cfg)
+	}
+	if isArchivePath(uri) {
+		return newArchiveBackend(uri, indexer.Cfg)
+	}
+	return &LocalBackend{path: uri, cfg: indexer.Cfg}, nil
+}
+
+// Generate the index file for the given path, and its subdirectories when
+// recursive. Generation stops with the context's error once it is canceled.
+//
+// In incremental mode the manifest is read from the target first, indexes
+// whose fingerprint is unchanged are not rendered or written, and the updated
+// manifest is written once generation succeeds.
+//
+// When pruning, index files in directories of the target that weren't indexed
+// are removed once generation succeeds.
+//
+// In keep-going mode, directories that can't be read or written are skipped
+// and the rest are still indexed. A *GenerateError listing the failures is
+// returned at the end, and nothing is pruned.
+//
+// The Result describes the directories indexed so far, and is returned even
+// when generation fails.
+func (i Indexer) Generate(ctx context.Context, parent *Item, path string) (*Result, error) {
+	i.stats = newRunStats()
+	err := i.run(ctx, parent, path)
+	return i.stats.result(), err
+}
+
+func (i Indexer) run(ctx context.Context, parent *Item, path string) error {
+	if i.Cfg.Incremental {
+		store, ok := i.Target.(ManifestStore)
+		if !ok {
+			return fmt.Errorf("incremental mode is not supported for target %s", i.Cfg.Target)
+		}
+
+		m, err := loadManifest(ctx, store, manifestFileName(i.Cfg))
+		if err != nil {
+			return err
+		}
+		i.manifest = m
+	}
+
+	if i.Cfg.Prune {
+		i.indexed = newIndexedDirs()
+	}
+
+	if i.Cfg.KeepGoing {
+		i.failures = &failures{}
+	}
+
+	if err := i.generate(ctx, parent, path); err != nil {
+		return err
+	}
+
+	failed := i.failures.err()
+
+	if i.Cfg.Prune {
+		if failed != nil {
+			log.Warn("Not pruning as some directories failed")
+		} else if err := i.prune(ctx); err != nil {
+			return err
+		}
+	}
+
+	if i.manifest != nil {
+		if err := i.manifest.save(ctx); err != nil {
+			return err
+		}
+	}
+
+	return failed
+}
+
+// generate writes the index for a single directory, recursing into its
+// subdirectories first.
+func (i Indexer) generate(ctx context.Context, parent *Item, path string) error {
+	var err error
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	relativePath := strings.TrimPrefix(path, i.Cfg.BasePath)
+
+	// Ensure relative path is prefixed with a slash. This will also set an
+	// empty base path to "/" (such as when listing the root of an S3 bucket).
+	// S3 keys don't have a leading slash, but we normalize for consistency
+	if !strings.HasPrefix(relativePath, "/") {
+		relativePath = "/" + relativePath
+	}
+
+	start := time.Now()
+	dir := DirResult{Path: relativePath, Status: DirFailed}
+	defer func() {
+		if i.stats != nil {
+			dir.Duration = time.Since(start)
+			i.stats.addDir(dir)
+		}
+	}()
+
+	items, hasNoIndex, err := i.Source.Read(ctx, path)
+	if err != nil {
+		return i.skipFailed(ctx, relativePath, "read", err)
+	}
+
+	// If hasNoIndex is true, skip this directory entirely
+	if hasNoIndex {
+		log.Debugf("Skipping generation for %s due to noindex file", path)
+		dir.Status = DirSkipped
+		return nil
+	}
+
+	// Merge the directory's own configuration file, which then also applies
+	// to its subdirectories.
+	i.Cfg, items, err = i.dirConfig(ctx, path, items)
+	if err != nil {
+		return i.skipFailed(ctx, relativePath, "read", err)
+	}
+
+	items, err = i.filterItems(ctx, path, items)
+	if err != nil {
+		return i.skipFailed(ctx, relativePath, "read", err)
+	}
+
+	items = i.dropNoIndex(path, relativePath, items)
+
+	for _, item := range items {
+		if !item.IsDir {
+			dir.Files++
+			dir.Bytes += item.Size
+		}
+	}
+
+	// Ensure the target directory exists before attempting to write or recurse
+	if err := i.Target.EnsureDirExists(ctx, relativePath); err != nil {
+		err = fmt.Errorf("failed to ensure target directory exists for %s: %w", relativePath, err)
+		return i.skipFailed(ctx, relativePath, "write", err)
+	}
+
+	// Generate the indexes of any subdirectories before aggregating their
+	// sizes and modification times into the parent.
+	if err := i.parseItems(ctx, path, items); err != nil {
+		return err
+	}
+
+	if parent != nil {
+		for _, item := range items {
+			parent.Size += item.Size
+
+			if !parent.HasMetadata || item.LastModified.After(parent.LastModified) {
+				parent.LastModified = item.LastModified
+			}
+
+			parent.HasMetadata = true
+		}
+	}
+
+	// Prepare template data regardless of whether items were found
+	data, err := i.data(items, path, relativePath)
+	if err != nil {
+		return err
+	}
+
+	// Only generate and write the index file if there are items to list.
+	// This handles the skipindex case (Read returns empty items) and empty directories.
+	if len(items) > 0 {
+		var tmpl *template.Template
+		var templStr string
+		if i.Cfg.Template != "" {
+			log.Debugf("Using custom template %s for %s", i.Cfg.Template, path)
+			templBytes, err := os.ReadFile(i.Cfg.Template)
+			if err != nil {
+				return err
+			}
+			templStr = string(templBytes)
+		} else {
+			log.Debugf("Using %s theme template for %s", i.Cfg.Theme, path)
+			templStr = getThemeTemplate(i.Cfg.Theme)
+		}
+
+		var fp string
+		if i.manifest != nil {
+			fp, err = fingerprint(templStr, i.Cfg.Minify, data)
+			if err != nil {
+				return err
+			}
+
+			if i.manifest.unchanged(relativePath, fp) {
+				log.Debugf("Skipping unchanged index for %s", path)
+				i.manifest.record(relativePath, fp)
+				i.recordIndexed(relativePath)
+				dir.Status = DirUnchanged
+				return nil
+			}
+		}
+
+		tmpl, err = template.New("index").Parse(templStr)
+		if err != nil {
+			return err
+		}
+
+		generated := new(strings.Builder)
+		if err := tmpl.Execute(generated, data); err != nil {
+			return err
+		}
+
+		output := generated.String()
+		if i.Cfg.Minify {
+			output = minifyHTML(generated.String())
+		}
+
+		dir.Status, err = i.write(ctx, data, output)
+		if err != nil {
+			dir.Status = DirFailed
+			return i.skipFailed(ctx, relativePath, "write", err)
+		}
+
+		if i.manifest != nil {
+			i.manifest.record(relativePath, fp)
+		}
+		i.recordIndexed(relativePath)
+	} else {
+		// Log if we are skipping the write due to empty items (skipindex or empty dir)
+		log.Debugf("Skipping index file generation for %s (no items or skipindex found)", path)
+		dir.Status = DirSkipped
+	}
+
+	return nil
+}
+
+// dropNoIndex removes the subdirectories that have a noindex file from the
+// items of a directory. They are reported as skipped when the directory's
+// subdirectories are indexed.
+func (i Indexer) dropNoIndex(path, relativePath string, items []*Item) []*Item {
+	kept := items[:0]
+	for _, item := range items {
+		if !item.NoIndex {
+			kept = append(kept, item)
+			continue
+		}
+
+		if i.stats != nil && i.recurseInto(path) {
+			dirPath := strings.TrimSuffix(relativePath, "/") + "/" + strings.TrimSuffix(item.Name, "/")
+			i.stats.addDir(DirResult{Path: dirPath, Status: DirSkipped})
+		}
+	}
+	return kept
+}
+
+// write writes the index file unless the target already has the same
+// content.
+func (i Indexer) write(ctx context.Context, data Data, content string) (DirStatus, error) {
+	if comparer, ok := i.Target.(IndexComparer); ok {
+		unchanged, err := comparer.IndexUnchanged(ctx, data, content)
+		if err != nil {
+			return DirFailed, fmt.Errorf("unable to compare index for %s: %w", data.RelativePath, err)
+		}
+		if unchanged {
+			log.Debugf("Index for %s is unchanged", data.RelativePath)
+			return DirUnchanged, nil
+		}
+	}
+
+	if err := i.Target.Write(ctx, data, content); err != nil {
+		return DirFailed, err
+	}
+
+	return DirWritten, nil
+}
+
+// skipFailed records the failure of a directory and returns nil in
+// keep-going mode, so that the remaining directories are still indexed.
+// Otherwise it returns the failure as a *DirError, or err unchanged once
+// generation is canceled.
+func (i Indexer) skipFailed(ctx context.Context, relativePath, op string, err error) error {
+	if ctx.Err() != nil {
+		return err
+	}
+
+	dirErr := &DirError{Path: relativePath, Op: op, Err: err}
+	if i.failures == nil {
+		return dirErr
+	}
+
+	i.failures.add(dirErr)
+	return nil
+}
+
+// recordIndexed records that the directory has an index file when pruning.
+func (i Indexer) recordIndexed(relativePath string) {
+	if i.indexed != nil {
+		i.indexed.add(relativePath)
+	}
+}
+
+// getThemeTemplate returns the template string for the given theme.
+func getThemeTemplate(theme string) string {
+	switch theme {
+	case "solarized":
+		return solarizedTemplate
+	case "nord":
+		return nordTemplate
+	case "dracula":
+		return draculaTemplate
+	default:
+		return defaultTemplate
+	}
+}
+
+func (i Indexer) data(items []*Item, path, relativePath string) (Data, error) {
+	data := Data{
+		RootPath:     i.Cfg.BasePath,
+		Items:        make([]TemplateItem, 0, len(items)),
+		Path:         path,
+		RelativePath: relativePath,
+		URL:          i.Cfg.BaseURL,
+		Title:        i.formatTitle(path, relativePath),
+	}
+
+	if path == i.Cfg.BasePath {
+		// Handle "link up from root" option
+		if i.Cfg.LinkUpFromRoot {
+			data.HasParent = true
+			data.ParentText = i.Cfg.LinkUpText
+			if data.ParentText == "" {
+				data.ParentText = "Go Up"
+			}
+
+			// Build the up link URL
+			upURL := i.Cfg.LinkUpURL
+			if upURL == "" {
+				upURL = ".."
+			}
+
+			// Respect the LinkToIndexes setting
+			if i.Cfg.LinkToIndexes {
+				upURL = strings.TrimSuffix(upURL, "/") + "/" + i.Cfg.IndexFile
+			}
+
+			// Combine with base URL if specified
+			if i.Cfg.BaseURL != "" {
+				var err error
+				data.ParentURL, err = joinURL(i.Cfg.BaseURL, upURL)
+				if err != nil {
+					log.Error("Error joining URL for link up from root:", err)
+					data.ParentURL = upURL
+				}
+			} else {
+				data.ParentURL = upURL
+			}
+		} else {
+			data.HasParent = false
+		}
+	} else {
+		parent := filepath.Dir(path)
+		// Calculate the relative parent path
+		relativeParent := strings.TrimPrefix(parent, i.Cfg.BasePath)
+		if !strings.HasPrefix(relativeParent, "/") {
+			relativeParent = "/" + relativeParent
+		}
+		data.Parent = resolveParentPath(i.Cfg.BaseURL, relativeParent, i.Cfg.IndexFile, i.Cfg.LinkToIndexes)
+		data.HasParent = parent != path
+		data.ParentText = "Go Up"
+		if data.HasParent {
+			data.ParentURL = resolveItemURL(i.Cfg.BaseURL, relativeParent, "..", true, i.Cfg.LinkToIndexes, i.Cfg.IndexFile)
+		}
+	}
+
+	// Process items within the data function to set their URLs
+	processedItems := make([]TemplateItem, 0, len(items))
+	for _, item := range items {
+		processedItem, err := i.processItemForData(path, item) // Rename to avoid confusion with recursive call
+		if err != nil {
+			return Data{}, err
+		}
+		processedItems = append(processedItems, processedItem)
+	}
+	data.Items = processedItems // Assign processed items with URLs
+
+	i.sort(&data.Items)
+	return data, nil
+}
+
+// processItemForData generates the URL for an item. Does NOT handle recursion.
+func (i Indexer) processItemForData(path string, item *Item) (TemplateItem, error) {
+	// Calculate the relative path by removing the base path
+	relativePath := strings.TrimPrefix(path, i.Cfg.BasePath)
+	// Ensure relative path is prefixed with a slash
+	if !strings.HasPrefix(relativePath, "/") {
+		relativePath = "/" + relativePath
+	}
+
+	processed := TemplateItem{
+		Name:       item.Name,
+		URL:        resolveItemURL(i.Cfg.BaseURL, relativePath, item.Name, item.IsDir, i.Cfg.LinkToIndexes, i.Cfg.IndexFile),
+		IsDir:      item.IsDir,
+		IsSymlink:  item.IsSymlink,
+		LinkTarget: item.LinkTarget,
+	}
+
+	if item.HasMetadata {
+		processed.Size = humanizeBytes(item.Size)
+		processed.LastModified = item.LastModified.Format(i.Cfg.DateFormat)
+	}
+
+	return processed, nil
+}
+
+// parseItems calls parseItem for each item. When concurrency is enabled, items
+// are handed to a free worker and otherwise processed in the calling
+// goroutine, so nested directories never wait on each other for a worker.
+// Once an item fails no more items are started, and the error of the first
+// failing item is returned.
+func (i Indexer) parseItems(ctx context.Context, path string, items []*Item) error {
+	if !i.recurseInto(path) {
+		return nil
+	}
+
+	errs := make([]error, len(items))
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed bool
+	)
+	record := func(n int, err error) {
+		if err == nil {
+			return
+		}
+		log.Errorf("Error generating index for %s: %v", items[n].Name, err)
+		mu.Lock()
+		errs[n] = err
+		failed = true
+		mu.Unlock()
+	}
+
+	for n, item := range items {
+		if !item.IsDir {
+			continue
+		}
+
+		mu.Lock()
+		stop := failed
+		mu.Unlock()
+		if stop || ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case i.workers <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-i.workers }()
+				record(n, i.parseItem(ctx, path, item))
+			}()
+		default:
+			record(n, i.parseItem(ctx, path, item))
+		}
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// recurseInto reports whether the subdirectories of path are indexed. They
+// are when recursive, unless path is already at the maximum depth.
+func (i Indexer) recurseInto(path string) bool {
+	if !i.Cfg.Recursive {
+		return false
+	}
+	if i.Cfg.MaxDepth == 0 {
+		return true
+	}
+
+	depth := 0
+	if relativePath := strings.Trim(strings.TrimPrefix(path, i.Cfg.BasePath), "/"); relativePath != "" {
+		depth = strings.Count(relativePath, "/") + 1
+	}
+
+	return depth < i.Cfg.MaxDepth
+}
+
+// parseItem handles the recursive call for directories.
+func (i Indexer) parseItem(ctx context.Context, path string, item *Item) error {
+	// If the item is a directory and recursive mode is enabled, generate its index
+	if item.IsDir && i.recurseInto(path) {
+		// Construct the full path for the subdirectory
+		subDirPath := filepath.Join(path, item.Name)
+		if err := i.generate(ctx, item, subDirPath); err != nil {
+			// Log the error but also return it to stop processing this branch
+			log.Errorf("Error generating index for subdirectory %s: %v", subDirPath, err)
+			return fmt.Errorf("error generating index for subdirectory %s: %w", subDirPath, err)
+		}
+	}
+	return nil // Return nil error if no recursion error occurred
+}
+
+func (i Indexer) formatTitle(path, relativePath string) string {
+	title := strings.Replace(i.Cfg.Title, "{source}", filepath.Base(i.Cfg.Source), -1)
+	title = strings.Replace(title, "{target}", filepath.Base(i.Cfg.Target), -1)
+	title = strings.Replace(title, "{path}", path, -1)
+	title = strings.Replace(title, "{relativePath}", relativePath, -1)
+
+	return title
+}
+
+func humanizeBytes(bytes int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB", "EB", "ZB", "YB"}
+	if bytes < 10 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	log := math.Log(float64(bytes)) / math.Log(1024)
+	index := int(log)
+	size := float64(bytes) / math.Pow(1024, float64(index))
+	return fmt.Sprintf("%.2f %s", size, units[index])
+}
+
+func minifyHTML(str string) string {
+	str = strings.ReplaceAll(str, "\n", "")
+	str = strings.ReplaceAll(str, "\t", "")
+	str = strings.ReplaceAll(str, "  ", "")
+	str = strings.ReplaceAll(str, "> <", "><")
+	return str
+}
+
+// indexMarkers returns the noindex and skipindex files among the names of the
+// files in a directory. Either is empty when the directory has none.
+func indexMarkers(cfg Config, fileNames []string) (noIndex, skipIndex string) {
+	for _, name := range fileNames {
+		if noIndex == "" && contains(cfg.NoIndexFiles, name) {
+			noIndex = name
+		}
+		if skipIndex == "" && contains(cfg.SkipIndexFiles, name) {
+			skipIndex = name
+		}
+	}
+
+	return noIndex, skipIndex
+}
+
+// skipListing reports whether a directory is left out of the index because it
+// has a noindex file, or is listed in its parent without an index of its own
+// because it has a skipindex file. A noindex file takes precedence. location
+// names the directory in the log.
+func skipListing(cfg Config, location string, fileNames []string) (noIndex, skipIndex bool) {
+	noIndexFile, skipIndexFile := indexMarkers(cfg, fileNames)
+	if noIndexFile != "" {
+		log.Infof("Skipping %s (found noindex file %s)", location, noIndexFile)
+		return true, false
+	}
+
+	if skipIndexFile != "" {
+		log.Infof(
+			"Skipping indexing of %s (found skipindex file %s), will include in parent directory",
+			location,
+			skipIndexFile,
+		)
+		return false, true
+	}
+
+	return false, false
+}
+
+// excludedDir reports whether a subdirectory is left out of its parent's
+// listing because it has a noindex file.
+func excludedDir(cfg Config, location string, fileNames []string) bool {
+	noIndexFile, _ := indexMarkers(cfg, fileNames)
+	if noIndexFile != "" {
+		log.Infof("Skipping %s (found noindex file %s)", location, noIndexFile)
+	}
+
+	return noIndexFile != ""
+}
+
+// dirEntryFileNames returns the names of the files, but not the directories,
+// in a directory listing.
+func dirEntryFileNames(entries []fs.DirEntry) []string {
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+// shouldSkip reports whether an item is left out of the listing, either as
+// an index file or because it matches one of the skip patterns. relativePath
+// is the item's slash-separated path from the root of the source.
+func shouldSkip(name, relativePath, index string, skips []string) bool {
+	if strings.HasSuffix(name, index) {
+		return true
+	}
+
+	return matchPatterns(name, relativePath, skips)
+}
+
+// matchPatterns reports whether an item matches any of the patterns. A
+// trailing slash on the name of a directory is ignored.
+func matchPatterns(name, relativePath string, patterns []string) bool {
+	name = strings.TrimSuffix(name, "/")
+	for _, pattern := range patterns {
+		if matchPattern(pattern, name, relativePath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPattern matches a skip or include pattern against an item's name and
+// its path from the root of the source. Patterns support globs such as "*.tmp" and "**" to
+// match any number of directories, as in "**/node_modules". A leading slash
+// anchors a pattern to the root of the source, as in "/projects/private/**".
+func matchPattern(pattern, name, relativePath string) bool {
+	if pattern == name {
+		return true
+	}
+
+	if !strings.HasPrefix(pattern, "/") {
+		if ok, _ := doublestar.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	ok, _ := doublestar.Match(strings.TrimPrefix(pattern, "/"), strings.Trim(relativePath, "/"))
+	return ok
+}
+
+// itemPath returns the slash-separated path of an item in dir from the root
+// of the source.
+func itemPath(cfg Config, dir, name string) string {
+	dir = filepath.ToSlash(strings.TrimPrefix(dir, cfg.BasePath))
+	return strings.Trim(path.Join("/", dir, name), "/")
+}
+
+func sha256Sum(content string) []byte {
+	sum := sha256.Sum256([]byte(content))
+	return sum[:]
+}
+
+func contains(arr []string, str string) bool {
+	for _, a := range arr {
+		if a == str {
+			return true
+		}
+	}
+	return false
+}
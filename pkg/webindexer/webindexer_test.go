@@ -1,10 +1,17 @@
 package webindexer
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -19,17 +26,17 @@ type MockSource struct {
 	mock.Mock
 }
 
-func (m *MockSource) Read(path string) ([]*Item, bool, error) {
+func (m *MockSource) Read(_ context.Context, path string) ([]*Item, bool, error) {
 	args := m.Called(path)
 	return args.Get(0).([]*Item), args.Bool(1), args.Error(2)
 }
 
-func (m *MockSource) Write(data Data, content string) error {
+func (m *MockSource) Write(_ context.Context, data Data, content string) error {
 	args := m.Called(data, content)
 	return args.Error(0)
 }
 
-func (m *MockSource) EnsureDirExists(relativePath string) error {
+func (m *MockSource) EnsureDirExists(_ context.Context, relativePath string) error {
 	args := m.Called(relativePath)
 	return args.Error(0)
 }
@@ -51,7 +58,7 @@ func TestIndexer_Generate(t *testing.T) {
 	// Write should NOT be called when Read returns empty items
 	// mockTarget.On("Write", mock.Anything, mock.Anything).Return(nil)
 
-	err := indexer.Generate(nil, "path/to/generate")
+	_, err := indexer.Generate(t.Context(), nil, "path/to/generate")
 	assert.NoError(t, err)
 
 	mockSource.AssertExpectations(t)
@@ -94,7 +101,7 @@ func TestCustomTemplate(t *testing.T) {
 	// Write should NOT be called when Read returns empty items
 	// mockTarget.On("Write", mock.Anything, mock.Anything).Return(nil)
 
-	err = indexer.Generate(nil, "path/to/generate")
+	_, err = indexer.Generate(t.Context(), nil, "path/to/generate")
 	assert.NoError(t, err)
 
 	// Check the file content
@@ -206,11 +213,73 @@ func TestShouldSkipURL(t *testing.T) {
 		"foo.txt",
 	}
 
-	assert.True(t, shouldSkip("index.html", "index.html", skips))
-	assert.True(t, shouldSkip("index.htm", "index.htm", skips))
-	assert.True(t, shouldSkip("foo.txt", "index.html", skips))
-	assert.False(t, shouldSkip("another-file.tar.gz", "index.html", skips))
-	assert.False(t, shouldSkip("something.html", "index.html", skips))
+	assert.True(t, shouldSkip("index.html", "index.html", "index.html", skips))
+	assert.True(t, shouldSkip("index.htm", "index.htm", "index.htm", skips))
+	assert.True(t, shouldSkip("foo.txt", "foo.txt", "index.html", skips))
+	assert.False(t, shouldSkip("another-file.tar.gz", "another-file.tar.gz", "index.html", skips))
+	assert.False(t, shouldSkip("something.html", "something.html", "index.html", skips))
+}
+
+func TestShouldSkipPatterns(t *testing.T) {
+	skips := []string{"*.tmp", "**/node_modules", ".git*", "/projects/private/**", "docs/*.md", "[draft]"}
+
+	tests := []struct {
+		relativePath string
+		want         bool
+	}{
+		{"file.tmp", true},
+		{"a/b/file.tmp", true},
+		{"file.tmp.txt", false},
+		{"node_modules", true},
+		{"a/b/node_modules", true},
+		{"a/node_modules_backup", false},
+		{".git", true},
+		{"sub/.gitignore", true},
+		{"projects/private", true},
+		{"projects/private/secret.txt", true},
+		{"projects/public", false},
+		{"other/projects/private", false},
+		{"docs/readme.md", true},
+		{"other/docs/readme.md", false},
+		{"readme.md", false},
+		// Exact names are still matched literally
+		{"[draft]", true},
+	}
+
+	for _, tt := range tests {
+		name := path.Base(tt.relativePath)
+		assert.Equal(t, tt.want, shouldSkip(name, tt.relativePath, "index.html", skips), tt.relativePath)
+	}
+
+	// Directories listed from object storage have a trailing slash
+	assert.True(t, matchPatterns("node_modules/", "a/node_modules", skips))
+}
+
+func TestItemPath(t *testing.T) {
+	assert.Equal(t, "a/b.txt", itemPath(Config{BasePath: "/srv/files"}, "/srv/files/a", "b.txt"))
+	assert.Equal(t, "b.txt", itemPath(Config{BasePath: "/srv/files"}, "/srv/files", "b.txt"))
+	assert.Equal(t, "sub/b.txt", itemPath(Config{BasePath: "prefix"}, "prefix/sub/", "b.txt"))
+	assert.Equal(t, "sub/dir", itemPath(Config{BasePath: "/"}, "sub/", "dir/"))
+}
+
+func TestSkipListing(t *testing.T) {
+	cfg := Config{NoIndexFiles: []string{".noindex"}, SkipIndexFiles: []string{".skipindex"}}
+
+	noIndex, skipIndex := skipListing(cfg, "dir", []string{"a.txt", ".skipindex"})
+	assert.False(t, noIndex)
+	assert.True(t, skipIndex)
+
+	// A noindex file takes precedence over a skipindex file
+	noIndex, skipIndex = skipListing(cfg, "dir", []string{".skipindex", ".noindex"})
+	assert.True(t, noIndex)
+	assert.False(t, skipIndex)
+
+	noIndex, skipIndex = skipListing(cfg, "dir", []string{"a.txt"})
+	assert.False(t, noIndex)
+	assert.False(t, skipIndex)
+
+	assert.True(t, excludedDir(cfg, "dir", []string{".noindex"}))
+	assert.False(t, excludedDir(cfg, "dir", []string{".skipindex"}))
 }
 
 func TestResolveParentPath(t *testing.T) {
@@ -437,7 +506,7 @@ func TestGenerate_Recursive(t *testing.T) {
 	}), mock.AnythingOfType("string")).Return(nil).Once()
 
 	// 5. Call Generate from the root source path
-	err = indexer.Generate(nil, absSourceDir)
+	_, err = indexer.Generate(t.Context(), nil, absSourceDir)
 	require.NoError(t, err)
 
 	// 6. Assert mock expectations were met
@@ -473,7 +542,7 @@ func TestLinkUpFromRoot(t *testing.T) {
 	}
 
 	// Generate data for the root path
-	items, _, err := sourceBackend.Read(sourceDir)
+	items, _, err := sourceBackend.Read(t.Context(), sourceDir)
 	require.NoError(t, err)
 
 	data, err := indexer.data(items, sourceDir, sourceDir)
@@ -510,3 +579,239 @@ func TestLinkUpFromRoot(t *testing.T) {
 	assert.False(t, data.HasParent, "HasParent should be false when LinkUpFromRoot is disabled")
 	assert.Empty(t, data.ParentURL, "ParentURL should be empty when LinkUpFromRoot is disabled")
 }
+
+// countingSource wraps a FileSource, tracking how many reads are in progress
+// at once and failing reads of the given directories.
+type countingSource struct {
+	FileSource
+	fail map[string]bool
+
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (c *countingSource) Read(ctx context.Context, path string) ([]*Item, bool, error) {
+	c.mu.Lock()
+	c.current++
+	c.max = max(c.max, c.current)
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.current--
+		c.mu.Unlock()
+	}()
+
+	// Give other workers a chance to overlap
+	time.Sleep(time.Millisecond)
+
+	if c.fail[path] {
+		return nil, false, fmt.Errorf("read %s: %w", path, errors.New("broken"))
+	}
+
+	return c.FileSource.Read(ctx, path)
+}
+
+// newConcurrencyTestFS builds a tree of nested directories with files of
+// different sizes and modification times.
+func newConcurrencyTestFS() fstest.MapFS {
+	fsys := fstest.MapFS{}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for a := range 6 {
+		for b := range 4 {
+			for c := range 3 {
+				name := fmt.Sprintf("dir%d/sub%d/leaf%d/file.txt", a, b, c)
+				fsys[name] = &fstest.MapFile{
+					Data:    []byte(strings.Repeat("x", a*100+b*10+c)),
+					ModTime: base.Add(time.Duration(a*b*c) * time.Hour),
+				}
+			}
+		}
+	}
+	return fsys
+}
+
+func generateToDir(t *testing.T, source FileSource, concurrency int) (string, error) {
+	t.Helper()
+
+	targetDir := t.TempDir()
+	cfg := Config{
+		Target:      targetDir,
+		Recursive:   true,
+		IndexFile:   "index.html",
+		SortBy:      "name",
+		Order:       "asc",
+		DateFormat:  "2006-01-02 15:04:05 MST",
+		Concurrency: concurrency,
+	}
+
+	indexer, err := NewWithSource(cfg, source)
+	require.NoError(t, err)
+
+	_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	return targetDir, err
+}
+
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = string(content)
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestGenerate_Concurrency(t *testing.T) {
+	fsys := newConcurrencyTestFS()
+
+	sequentialDir, err := generateToDir(t, NewFSSource(fsys, Config{IndexFile: "index.html"}), 1)
+	require.NoError(t, err)
+	expected := readTree(t, sequentialDir)
+	require.Len(t, expected, 1+6+6*4+6*4*3)
+
+	source := &countingSource{FileSource: NewFSSource(fsys, Config{IndexFile: "index.html"})}
+	concurrentDir, err := generateToDir(t, source, 4)
+	require.NoError(t, err)
+
+	// The output, including aggregated directory sizes and dates, matches the
+	// sequential run.
+	assert.Equal(t, expected, readTree(t, concurrentDir))
+	assert.LessOrEqual(t, source.max, 4)
+	assert.Greater(t, source.max, 1)
+}
+
+func TestGenerate_ConcurrencyError(t *testing.T) {
+	fsys := newConcurrencyTestFS()
+
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			source := &countingSource{
+				FileSource: NewFSSource(fsys, Config{IndexFile: "index.html"}),
+				fail:       map[string]bool{"/dir1/sub2": true, "/dir4": true},
+			}
+
+			_, err := generateToDir(t, source, concurrency)
+			require.Error(t, err)
+			// The error of the first failing directory is returned
+			assert.Contains(t, err.Error(), "read /dir1/sub2")
+		})
+	}
+}
+
+func TestGenerate_MaxDepth(t *testing.T) {
+	tests := []struct {
+		depth   int
+		indexes int
+		// deepest is the deepest directory given an index, which still lists
+		// its subdirectories. Reading those fails the test.
+		deepest string
+		sub     string
+	}{
+		{depth: 1, indexes: 1 + 6, deepest: "/dir0", sub: "sub0"},
+		{depth: 2, indexes: 1 + 6 + 6*4, deepest: "/dir0/sub0", sub: "leaf0"},
+		{depth: 3, indexes: 1 + 6 + 6*4 + 6*4*3},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("max depth %d", tt.depth), func(t *testing.T) {
+			source := &countingSource{
+				FileSource: NewFSSource(newConcurrencyTestFS(), Config{IndexFile: "index.html"}),
+				fail:       map[string]bool{path.Join(tt.deepest, tt.sub): tt.deepest != ""},
+			}
+
+			targetDir := t.TempDir()
+			cfg := Config{
+				Target:     targetDir,
+				Recursive:  true,
+				MaxDepth:   tt.depth,
+				IndexFile:  "index.html",
+				SortBy:     "name",
+				Order:      "asc",
+				DateFormat: "2006-01-02 15:04:05 MST",
+			}
+			indexer, err := NewWithSource(cfg, source)
+			require.NoError(t, err)
+
+			_, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+			require.NoError(t, err)
+
+			tree := readTree(t, targetDir)
+			assert.Len(t, tree, tt.indexes)
+			if tt.deepest != "" {
+				deepest := filepath.Join(filepath.FromSlash(strings.TrimPrefix(tt.deepest, "/")), "index.html")
+				assert.Contains(t, tree[deepest], tt.sub)
+			}
+		})
+	}
+}
+
+func TestGenerate_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	source := &countingSource{FileSource: NewFSSource(newConcurrencyTestFS(), Config{IndexFile: "index.html"})}
+
+	targetDir := t.TempDir()
+	cfg := Config{
+		Target:      targetDir,
+		Recursive:   true,
+		IndexFile:   "index.html",
+		SortBy:      "name",
+		Order:       "asc",
+		Concurrency: 4,
+	}
+	indexer, err := NewWithSource(cfg, source)
+	require.NoError(t, err)
+
+	cancel()
+	_, err = indexer.Generate(ctx, nil, indexer.Cfg.BasePath)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, readTree(t, targetDir))
+}
+
+func TestGenerate_SkipsUnchangedWrites(t *testing.T) {
+	fsys := newConcurrencyTestFS()
+	targetDir := t.TempDir()
+	cfg := Config{
+		Target:     targetDir,
+		Recursive:  true,
+		IndexFile:  "index.html",
+		SortBy:     "name",
+		Order:      "asc",
+		DateFormat: "2006-01-02 15:04:05 MST",
+	}
+
+	indexer, err := NewWithSource(cfg, NewFSSource(fsys, cfg))
+	require.NoError(t, err)
+	result, err := indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(103), result.Written)
+
+	rootIndex := filepath.Join(targetDir, "index.html")
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(rootIndex, old, old))
+
+	// Only the directory whose listing changed is rewritten. The new file is
+	// empty, so the sizes aggregated into the parents don't change.
+	fsys["dir0/sub0/leaf0/extra.txt"] = &fstest.MapFile{}
+	indexer, err = NewWithSource(cfg, NewFSSource(fsys, cfg))
+	require.NoError(t, err)
+	result, err = indexer.Generate(t.Context(), nil, indexer.Cfg.BasePath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Written)
+	assert.Equal(t, int64(102), result.Unchanged)
+
+	info, err := os.Stat(rootIndex)
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(old), "unchanged index should not be rewritten")
+}